@@ -0,0 +1,350 @@
+package hugo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+	"unicode"
+)
+
+// defaultArchetype is what hugo new uses when neither the site nor its
+// theme has a matching archetype
+const defaultArchetype = `+++
+date = '{{ .Date }}'
+draft = true
+title = '{{ replace .File.ContentBaseName "-" " " | title }}'
++++
+`
+
+// Archetype is a template for new content, either a single Markdown file
+// or a directory that becomes a page bundle.
+type Archetype struct {
+	Name  string // e.g. "posts" or "default"
+	Path  string // empty for the built-in default
+	Dir   bool   // a directory archetype, creates a bundle
+	Theme string // the theme it comes from, empty for the site
+}
+
+// Label describes the archetype for a picker
+func (a Archetype) Label() string {
+	label := a.Name
+	if a.Dir {
+		label += " (bundle)"
+	}
+	if a.Theme != "" {
+		label += " · " + a.Theme
+	} else if a.Path == "" {
+		label += " (built-in)"
+	}
+	return label
+}
+
+// FindArchetypes lists the archetypes of the site and then of each theme
+// directory, in the order Hugo looks them up.
+func FindArchetypes(siteDir string, themeDirs []string) []Archetype {
+	archetypes := readArchetypes(filepath.Join(siteDir, "archetypes"), "")
+	for _, dir := range themeDirs {
+		archetypes = append(archetypes, readArchetypes(filepath.Join(dir, "archetypes"), filepath.Base(dir))...)
+	}
+	return archetypes
+}
+
+func readArchetypes(dir, theme string) []Archetype {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var archetypes []Archetype
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			archetypes = append(archetypes, Archetype{Name: name, Path: filepath.Join(dir, name), Dir: true, Theme: theme})
+		} else if strings.HasSuffix(strings.ToLower(name), ".md") {
+			archetypes = append(archetypes, Archetype{Name: strings.TrimSuffix(name, filepath.Ext(name)), Path: filepath.Join(dir, name), Theme: theme})
+		}
+	}
+	sort.SliceStable(archetypes, func(i, j int) bool { return archetypes[i].Name < archetypes[j].Name })
+	return archetypes
+}
+
+// LookupArchetype returns the archetype hugo new picks for kind, which is
+// the section unless given explicitly: the site's <kind>, the site's
+// default, then the same in the themes, then the built-in default.
+func LookupArchetype(archetypes []Archetype, kind string) Archetype {
+	names := []string{"default"}
+	if kind != "" {
+		names = []string{kind, "default"}
+	}
+
+	// Site archetypes are listed before theme ones
+	var themes []string
+	seen := map[string]bool{}
+	for _, a := range archetypes {
+		if !seen[a.Theme] {
+			themes = append(themes, a.Theme)
+			seen[a.Theme] = true
+		}
+	}
+
+	for _, theme := range themes {
+		for _, name := range names {
+			for _, a := range archetypes {
+				if a.Theme == theme && a.Name == name {
+					return a
+				}
+			}
+		}
+	}
+	return Archetype{Name: "default"}
+}
+
+// ArchetypeSite is .Site in an archetype template, the settings of the
+// site in the language of the new content
+type ArchetypeSite struct {
+	Title        string
+	BaseURL      string
+	LanguageCode string
+	Params       map[string]interface{}
+	Language     ArchetypeLanguage
+}
+
+// ArchetypeLanguage is .Site.Language in an archetype template
+type ArchetypeLanguage struct {
+	Lang              string
+	LanguageName      string
+	LanguageCode      string
+	LanguageDirection string
+	Weight            int
+}
+
+// NewContent creates content at path from archetype a, the way hugo new
+// does. path is a .md file, or the bundle directory for a directory
+// archetype. contentDir is used to work out the section. It returns the
+// Markdown file to open.
+func NewContent(a Archetype, contentDir, path string, site ArchetypeSite, now time.Time) (string, error) {
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("%s already exists", filepath.Base(path))
+	}
+
+	if !a.Dir {
+		src := []byte(defaultArchetype)
+		if a.Path != "" {
+			var err error
+			if src, err = os.ReadFile(a.Path); err != nil {
+				return "", err
+			}
+		}
+		return path, renderArchetypeFile(string(src), contentDir, path, site, now)
+	}
+
+	// Directory archetype: copy everything, rendering the Markdown files
+	var index string
+	err := filepath.WalkDir(a.Path, func(src string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(a.Path, src)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(path, rel)
+		if d.IsDir() {
+			return os.MkdirAll(dst, 0755)
+		}
+
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(strings.ToLower(dst), ".md") {
+			return os.WriteFile(dst, data, 0644)
+		}
+		if index == "" || rel == "index.md" || rel == "_index.md" {
+			index = dst
+		}
+		return renderArchetypeFile(string(data), contentDir, dst, site, now)
+	})
+	if err != nil {
+		return "", err
+	}
+	return index, nil
+}
+
+// archetypeFile is .File in an archetype template
+type archetypeFile struct {
+	ContentBaseName string
+	BaseFileName    string
+	LogicalName     string
+	Ext             string
+	Dir             string
+	Path            string
+	Section         string
+}
+
+// archetypeData is the context an archetype template is executed with
+type archetypeData struct {
+	Name    string
+	Date    string
+	Type    string
+	Kind    string
+	Section string
+	File    archetypeFile
+	Site    ArchetypeSite
+}
+
+func renderArchetypeFile(src, contentDir, path string, site ArchetypeSite, now time.Time) error {
+	rel, err := filepath.Rel(contentDir, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	rel = filepath.ToSlash(rel)
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	kind := "page"
+	if base == "index" || base == "_index" {
+		// Bundles are named after their directory
+		if base == "_index" {
+			kind = "section"
+		}
+		base = filepath.Base(filepath.Dir(path))
+	}
+
+	dir := filepath.ToSlash(filepath.Dir(rel)) + "/"
+	if dir == "./" {
+		dir = ""
+	}
+
+	section := ""
+	if i := strings.IndexByte(rel, '/'); i >= 0 {
+		section = rel[:i]
+	}
+	typ := section
+	if typ == "" {
+		typ = "page"
+	}
+
+	data := archetypeData{
+		Name:    base,
+		Date:    now.Format(time.RFC3339),
+		Type:    typ,
+		Kind:    kind,
+		Section: section,
+		File: archetypeFile{
+			ContentBaseName: base,
+			BaseFileName:    strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+			LogicalName:     filepath.Base(path),
+			Ext:             strings.TrimPrefix(filepath.Ext(path), "."),
+			Dir:             dir,
+			Path:            rel,
+			Section:         section,
+		},
+		Site: site,
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Funcs(archetypeFuncs(now)).Parse(src)
+	if err != nil {
+		return err
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			emptyMissing(t.Tree.Root)
+		}
+	}
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// emptyMissing ends the pipeline of every action under node with orEmpty,
+// so a missing value such as an unset .Site.Params key prints nothing, as
+// with hugo new, rather than <no value>
+func emptyMissing(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			emptyMissing(child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) == 0 {
+			orEmpty := &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos}
+			orEmpty.Args = []parse.Node{parse.NewIdentifier("orEmpty").SetPos(n.Pos)}
+			n.Pipe.Cmds = append(n.Pipe.Cmds, orEmpty)
+		}
+	case *parse.IfNode:
+		emptyMissing(n.List)
+		emptyMissing(n.ElseList)
+	case *parse.RangeNode:
+		emptyMissing(n.List)
+		emptyMissing(n.ElseList)
+	case *parse.WithNode:
+		emptyMissing(n.List)
+		emptyMissing(n.ElseList)
+	}
+}
+
+// archetypeFuncs are the Hugo template functions commonly used in
+// archetypes
+func archetypeFuncs(now time.Time) template.FuncMap {
+	return template.FuncMap{
+		"replace": func(s, old, new string) string { return strings.ReplaceAll(s, old, new) },
+		"title":   titleCase,
+		"lower":   strings.ToLower,
+		"upper":   strings.ToUpper,
+		"trim":    func(s, cutset string) string { return strings.Trim(s, cutset) },
+		"humanize": func(s string) string {
+			s = strings.NewReplacer("-", " ", "_", " ").Replace(s)
+			r := []rune(s)
+			if len(r) > 0 {
+				r[0] = unicode.ToUpper(r[0])
+			}
+			return string(r)
+		},
+		"now": func() time.Time { return now },
+		"dateFormat": func(layout string, t time.Time) string {
+			return t.Format(layout)
+		},
+		"default": func(def, val interface{}) interface{} {
+			if val == nil || val == "" {
+				return def
+			}
+			return val
+		},
+		"printf": fmt.Sprintf,
+		"orEmpty": func(v interface{}) interface{} {
+			if v == nil {
+				return ""
+			}
+			return v
+		},
+	}
+}
+
+// titleCase capitalises the first letter of every word
+func titleCase(s string) string {
+	r := []rune(s)
+	for i := range r {
+		if i == 0 || unicode.IsSpace(r[i-1]) {
+			r[i] = unicode.ToUpper(r[i])
+		}
+	}
+	return string(r)
+}
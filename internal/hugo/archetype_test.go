@@ -0,0 +1,83 @@
+package hugo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewContent(t *testing.T) {
+	site := ArchetypeSite{
+		Title:   "My site",
+		BaseURL: "https://example.org/",
+		Params:  map[string]interface{}{"author": "Ann"},
+		Language: ArchetypeLanguage{
+			Lang:         "en",
+			LanguageName: "English",
+		},
+	}
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		archetype string
+		path      string
+		want      string
+	}{
+		{
+			name:      "built-in default",
+			archetype: defaultArchetype,
+			path:      "posts/my-first-post.md",
+			want:      "+++\ndate = '2024-05-01T10:00:00Z'\ndraft = true\ntitle = 'My First Post'\n+++\n",
+		},
+		{
+			name:      "site settings",
+			archetype: "author: {{ .Site.Params.author }}\nsite: {{ .Site.Title }} {{ .Site.BaseURL }}\nlang: {{ .Site.Language.Lang }}\n",
+			path:      "posts/a.md",
+			want:      "author: Ann\nsite: My site https://example.org/\nlang: en\n",
+		},
+		{
+			name:      "missing param is empty",
+			archetype: "image: {{ .Site.Params.image }}|{{ .Site.Params.image | default \"none\" }}\n",
+			path:      "posts/a.md",
+			want:      "image: |none\n",
+		},
+		{
+			name:      "missing param in a branch",
+			archetype: "{{ with .Site.Params }}{{ .image }}{{ end }}{{ if true }}[{{ .Site.Params.image }}]{{ end }}\n",
+			path:      "posts/a.md",
+			want:      "[]\n",
+		},
+		{
+			name:      "file names",
+			archetype: "{{ .File.LogicalName }} {{ .File.ContentBaseName }} {{ .File.Dir }} {{ .Section }} {{ .Kind }}\n",
+			path:      "posts/trip/index.md",
+			want:      "index.md trip posts/trip/ posts page\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "archetype.md")
+			if err := os.WriteFile(src, []byte(tt.archetype), 0644); err != nil {
+				t.Fatal(err)
+			}
+			contentDir := filepath.Join(dir, "content")
+			path := filepath.Join(contentDir, filepath.FromSlash(tt.path))
+
+			file, err := NewContent(Archetype{Name: "default", Path: src}, contentDir, path, site, now)
+			if err != nil {
+				t.Fatalf("NewContent: %v", err)
+			}
+			got, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
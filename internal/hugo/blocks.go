@@ -0,0 +1,182 @@
+package hugo
+
+import (
+	"strings"
+)
+
+// Block is a paragraph, heading, list or code block of markdown
+type Block struct {
+	Text       string
+	Heading    int // The level of a heading, 0 for other blocks
+	Start, End int // Byte offsets of the block in the markdown it was split from
+}
+
+// SplitBlocks splits markdown into its blocks, which are separated by blank
+// lines. Fenced code blocks stay whole.
+func SplitBlocks(body string) []Block {
+	var blocks []Block
+	start, end := -1, 0 // The lines of the block being read
+	fence := ""
+
+	flush := func() {
+		if start >= 0 {
+			text := strings.ReplaceAll(body[start:end], "\r\n", "\n")
+			blocks = append(blocks, Block{Text: text, Heading: headingLevel(text), Start: start, End: end})
+			start = -1
+		}
+	}
+	add := func(lineStart, lineEnd int) {
+		if start < 0 {
+			start = lineStart
+		}
+		end = lineEnd
+	}
+
+	for off := 0; off < len(body); {
+		lineEnd, next := len(body), len(body)
+		if i := strings.IndexByte(body[off:], '\n'); i >= 0 {
+			lineEnd, next = off+i, off+i+1
+		}
+		lineEnd = off + len(strings.TrimSuffix(body[off:lineEnd], "\r"))
+		line := body[off:lineEnd]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case fence != "":
+			add(off, lineEnd)
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+				flush()
+			}
+		case strings.HasPrefix(trimmed, "```"), strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence = trimmed[:3]
+			add(off, lineEnd)
+		case trimmed == "":
+			flush()
+		case headingLevel(trimmed) > 0:
+			// A heading is a block of its own even without blank lines
+			flush()
+			add(off, lineEnd)
+			flush()
+		default:
+			add(off, lineEnd)
+		}
+		off = next
+	}
+	flush()
+	return blocks
+}
+
+// ReplaceBlock returns body with the text of b, a block split from it,
+// replaced. The rest of body stays as it was, byte for byte.
+func ReplaceBlock(body string, b Block, text string) string {
+	return body[:b.Start] + lineEndings(body, text) + body[b.End:]
+}
+
+// InsertBlock adds text to body as a block of its own at offset at, the
+// start of body or the end of one of its blocks. It returns the new body
+// and the block added.
+func InsertBlock(body string, at int, text string) (string, Block) {
+	text = lineEndings(body, text)
+	nl := "\n"
+	if strings.Contains(body, "\r\n") {
+		nl = "\r\n"
+	}
+
+	before, after := "", ""
+	switch {
+	case at > 0:
+		before = nl + nl
+	case strings.TrimSpace(body) != "":
+		after = nl + nl
+	default:
+		after = nl
+	}
+	b := Block{
+		Text:    strings.ReplaceAll(text, "\r\n", "\n"),
+		Heading: headingLevel(text),
+		Start:   at + len(before),
+		End:     at + len(before) + len(text),
+	}
+	return body[:at] + before + text + after + body[at:], b
+}
+
+// lineEndings writes text with the line endings of body
+func lineEndings(body, text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if strings.Contains(body, "\r\n") {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	return text
+}
+
+// JoinBlocks puts blocks back together as markdown
+func JoinBlocks(blocks []string) string {
+	var parts []string
+	for _, b := range blocks {
+		if b = strings.TrimSpace(b); b != "" {
+			parts = append(parts, b)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+func headingLevel(text string) int {
+	level := 0
+	for level < len(text) && text[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(text) && text[level] != ' ') {
+		return 0
+	}
+	return level
+}
+
+// AlignBlocks pairs the blocks of a page with those of its translation.
+// Sections, which start at a heading, are paired in order, and so are the
+// blocks inside them. Each pair holds the index of a block in src and dst,
+// or -1 where one side has no counterpart.
+func AlignBlocks(src, dst []Block) [][2]int {
+	srcSections := sections(src)
+	dstSections := sections(dst)
+
+	var pairs [][2]int
+	for i := 0; i < len(srcSections) || i < len(dstSections); i++ {
+		var s, d []int
+		if i < len(srcSections) {
+			s = srcSections[i]
+		}
+		if i < len(dstSections) {
+			d = dstSections[i]
+		}
+		for j := 0; j < len(s) || j < len(d); j++ {
+			pair := [2]int{-1, -1}
+			if j < len(s) {
+				pair[0] = s[j]
+			}
+			if j < len(d) {
+				pair[1] = d[j]
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// sections groups the indexes of blocks by heading. The first section
+// holds what comes before the first heading, and is empty when there is
+// nothing there.
+func sections(blocks []Block) [][]int {
+	out := [][]int{nil}
+	for i, b := range blocks {
+		if b.Heading > 0 {
+			out = append(out, nil)
+		}
+		out[len(out)-1] = append(out[len(out)-1], i)
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+package hugo
+
+import (
+	"strings"
+	"testing"
+)
+
+const blocksBody = `Intro paragraph
+over two lines.
+## Heading
+- item one
+  continued
+- item two
+
+    indented code
+    more code
+
+` + "```go\nfunc main() {\n\n}\n```" + `
+Last line`
+
+func TestSplitBlocksOffsets(t *testing.T) {
+	for _, body := range []string{blocksBody, blocksBody + "\n", strings.ReplaceAll(blocksBody, "\n", "\r\n"), "", "\n\n"} {
+		blocks := SplitBlocks(body)
+		out := body
+		// Unchanged blocks put back leave the body as it was
+		for i := len(blocks) - 1; i >= 0; i-- {
+			out = ReplaceBlock(out, blocks[i], blocks[i].Text)
+		}
+		if out != body {
+			t.Errorf("round trip of %q = %q", body, out)
+		}
+	}
+
+	texts := []string{
+		"Intro paragraph\nover two lines.",
+		"## Heading",
+		"- item one\n  continued\n- item two",
+		"    indented code\n    more code",
+		"```go\nfunc main() {\n\n}\n```",
+		"Last line",
+	}
+	blocks := SplitBlocks(blocksBody)
+	if len(blocks) != len(texts) {
+		t.Fatalf("got %d blocks, want %d", len(blocks), len(texts))
+	}
+	for i, b := range blocks {
+		if b.Text != texts[i] {
+			t.Errorf("block %d = %q, want %q", i, b.Text, texts[i])
+		}
+		if blocksBody[b.Start:b.End] != texts[i] {
+			t.Errorf("block %d at %d:%d is %q", i, b.Start, b.End, blocksBody[b.Start:b.End])
+		}
+	}
+}
+
+func TestReplaceBlock(t *testing.T) {
+	blocks := SplitBlocks(blocksBody)
+	out := ReplaceBlock(blocksBody, blocks[2], "- first\n  still first")
+	want := strings.Replace(blocksBody, "- item one\n  continued\n- item two", "- first\n  still first", 1)
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+
+	crlf := "# Title\r\nText\r\n"
+	b := SplitBlocks(crlf)[1]
+	if out := ReplaceBlock(crlf, b, "One\nTwo"); out != "# Title\r\nOne\r\nTwo\r\n" {
+		t.Errorf("CRLF body = %q", out)
+	}
+}
+
+func TestInsertBlock(t *testing.T) {
+	tests := []struct {
+		body string
+		at   int
+		want string
+	}{
+		{"", 0, "New\n"},
+		{"First\n", 0, "New\n\nFirst\n"},
+		{"First\n\nSecond\n", len("First"), "First\n\nNew\n\nSecond\n"},
+		{"First", len("First"), "First\n\nNew"},
+	}
+	for _, tt := range tests {
+		out, b := InsertBlock(tt.body, tt.at, "New")
+		if out != tt.want {
+			t.Errorf("InsertBlock(%q, %d) = %q, want %q", tt.body, tt.at, out, tt.want)
+		}
+		if out[b.Start:b.End] != "New" {
+			t.Errorf("InsertBlock(%q, %d) block at %d:%d", tt.body, tt.at, b.Start, b.End)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package hugo
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BundleIndex returns the content file of the page bundle in dir:
+// index.md for a leaf bundle, or _index.md for a branch bundle (a section)
+// when branch is set. Translations such as index.ar.md count too, with
+// index.md preferred. It is empty when dir isn't such a bundle.
+func BundleIndex(dir string, branch bool) string {
+	name := "index"
+	if branch {
+		name = "_index"
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	found := ""
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".md") {
+			continue
+		}
+		stem := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if stem == name {
+			return filepath.Join(dir, entry.Name())
+		}
+		if strings.HasPrefix(stem, name+".") && found == "" {
+			found = filepath.Join(dir, entry.Name())
+		}
+	}
+	return found
+}
+
+// IsBundleIndex reports whether path is the content file of a bundle, and
+// whether that bundle is a branch
+func IsBundleIndex(path string) (ok, branch bool) {
+	stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	stem, _, _ = strings.Cut(stem, ".")
+	return stem == "index" || stem == "_index", stem == "_index"
+}
+
+// BundleResources returns the resources of the bundle whose content file
+// is index, relative to the bundle directory. Leaf bundles own every other
+// file below them, branch bundles only the non-Markdown files next to
+// their _index.md.
+func BundleResources(index string) []string {
+	ok, branch := IsBundleIndex(index)
+	if !ok {
+		return nil
+	}
+	dir := filepath.Dir(index)
+
+	var resources []string
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != dir && (branch || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		if isIndex, _ := IsBundleIndex(p); isIndex && filepath.Dir(p) == dir {
+			return nil
+		}
+		if branch && strings.EqualFold(filepath.Ext(p), ".md") {
+			return nil // pages of the section, not resources
+		}
+		rel, _ := filepath.Rel(dir, p)
+		resources = append(resources, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(resources)
+	return resources
+}
+
+// ResourceMeta returns the entry of the resources front matter that
+// applies to a resource, matching its src glob the way Hugo does: the
+// first matching entry wins.
+func ResourceMeta(meta map[string]interface{}, name string) map[string]interface{} {
+	var list []interface{}
+	switch v := meta["resources"].(type) {
+	case []interface{}:
+		list = v
+	case []map[string]interface{}:
+		for _, item := range v {
+			list = append(list, item)
+		}
+	}
+
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		src, _ := m["src"].(string)
+		if matched, _ := path.Match(strings.ToLower(src), strings.ToLower(name)); matched {
+			return m
+		}
+		// ** matches across directories
+		if strings.Contains(src, "**") {
+			prefix := strings.ToLower(strings.Split(src, "**")[0])
+			if strings.HasPrefix(strings.ToLower(name), prefix) {
+				return m
+			}
+		}
+	}
+	return nil
+}
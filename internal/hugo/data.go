@@ -0,0 +1,165 @@
+package hugo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DataFile is a standalone TOML, YAML or JSON document, such as a site
+// config or data file, that keeps its formatting when written back.
+type DataFile struct {
+	Data   map[string]interface{}
+	List   []interface{} // The items of a YAML or JSON file that is a list, Data is nil then
+	Format string        // "yaml", "toml" or "json"
+
+	source  frontMatter
+	trailer string // whitespace after a JSON object
+
+	// A list is written back as it was until it is edited, then encoded
+	// as a whole
+	raw  string
+	orig []interface{}
+}
+
+// ParseData parses a document in the given format
+func ParseData(content, format string) (*DataFile, error) {
+	d := &DataFile{Format: format}
+	if isListDocument(content, format) {
+		return d, d.parseList(content)
+	}
+
+	var err error
+	switch format {
+	case "toml":
+		d.source, d.Data, err = parseTOML(content)
+	case "yaml":
+		d.source, d.Data, err = parseYAML(content)
+	case "json":
+		trimmed := strings.TrimRight(content, " \t\r\n")
+		d.trailer = content[len(trimmed):]
+		if strings.TrimSpace(trimmed) == "" {
+			trimmed = "{}"
+		}
+		d.source, d.Data, err = parseJSON(trimmed)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Keys returns the top-level keys in the order they appear in the file
+func (d *DataFile) Keys() []string {
+	var known []string
+	if d.source != nil {
+		known = d.source.keys()
+	}
+	return orderedKeys(known, d.Data)
+}
+
+// ToString renders the document, writing untouched keys back as they were
+func (d *DataFile) ToString() (string, error) {
+	if d.Data == nil && d.List != nil {
+		return d.encodeList()
+	}
+	source := d.source
+	if source == nil {
+		switch d.Format {
+		case "toml":
+			source = &tomlDoc{}
+		case "json":
+			source = &jsonDoc{}
+			d.trailer = "\n"
+		default:
+			source = &yamlDoc{indent: 2}
+		}
+	}
+
+	out, err := source.encode(d.Data)
+	if err != nil {
+		return "", err
+	}
+	return out + d.trailer, nil
+}
+
+// isListDocument reports whether a YAML or JSON document is a list rather
+// than a map
+func isListDocument(content, format string) bool {
+	switch format {
+	case "json":
+		return strings.HasPrefix(strings.TrimSpace(content), "[")
+	case "yaml":
+		var root yaml.Node
+		if err := yaml.Unmarshal([]byte(content), &root); err != nil || len(root.Content) == 0 {
+			return false
+		}
+		return root.Content[0].Kind == yaml.SequenceNode
+	}
+	return false
+}
+
+func (d *DataFile) parseList(content string) error {
+	d.raw = content
+	unmarshal := yaml.Unmarshal
+	if d.Format == "json" {
+		unmarshal = json.Unmarshal
+	}
+	if err := unmarshal([]byte(content), &d.List); err != nil {
+		return err
+	}
+	// A second copy, so in-place edits of nested values are noticed
+	return unmarshal([]byte(content), &d.orig)
+}
+
+func (d *DataFile) encodeList() (string, error) {
+	if sameValue(d.orig, d.List) {
+		return d.raw, nil
+	}
+	lines := splitLines(d.raw)
+	if d.Format == "json" {
+		unit := "  "
+		for _, line := range lines[min(1, len(lines)):] {
+			if indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]; indent != "" {
+				unit = indent
+				break
+			}
+		}
+		buf := new(bytes.Buffer)
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", unit)
+		if err := enc.Encode(d.List); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	// Comments and key order of the items carry over
+	n := new(yaml.Node)
+	if err := n.Encode(d.List); err != nil {
+		return "", err
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(d.raw), &root); err == nil && len(root.Content) > 0 {
+		keepStyle(n, root.Content[0])
+		n.HeadComment = root.Content[0].HeadComment
+	}
+	doc := &yaml.Node{Kind: yaml.DocumentNode, HeadComment: root.HeadComment, FootComment: root.FootComment, Content: []*yaml.Node{n}}
+
+	buf := new(bytes.Buffer)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(detectIndent(lines))
+	if err := enc.Encode(doc); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
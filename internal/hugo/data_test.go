@@ -0,0 +1,81 @@
+package hugo
+
+import (
+	"strings"
+	"testing"
+)
+
+const yamlRecords = `# Team
+- name: Ann # lead
+  role: editor
+# Second
+- name: Bob # new
+  role: writer
+# Third
+- name: Cy # intern
+  role: writer
+`
+
+func TestDataListEdit(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(list []interface{}) []interface{}
+		want string
+	}{
+		{
+			name: "unchanged",
+			edit: func(list []interface{}) []interface{} { return list },
+			want: yamlRecords,
+		},
+		{
+			name: "removed record",
+			edit: func(list []interface{}) []interface{} { return append(list[:1:1], list[2]) },
+			want: strings.Replace(yamlRecords, "# Second\n- name: Bob # new\n  role: writer\n", "", 1),
+		},
+		{
+			name: "moved record",
+			edit: func(list []interface{}) []interface{} { return []interface{}{list[0], list[2], list[1]} },
+			want: `# Team
+- name: Ann # lead
+  role: editor
+# Third
+- name: Cy # intern
+  role: writer
+# Second
+- name: Bob # new
+  role: writer
+`,
+		},
+		{
+			name: "removed and edited record",
+			edit: func(list []interface{}) []interface{} {
+				list[2].(map[string]interface{})["role"] = "editor"
+				return append(list[:1:1], list[2])
+			},
+			want: `# Team
+- name: Ann # lead
+  role: editor
+# Third
+- name: Cy # intern
+  role: editor
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseData(yamlRecords, "yaml")
+			if err != nil {
+				t.Fatalf("ParseData: %v", err)
+			}
+			d.List = tt.edit(d.List)
+			out, err := d.ToString()
+			if err != nil {
+				t.Fatalf("ToString: %v", err)
+			}
+			if out != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", out, tt.want)
+			}
+		})
+	}
+}
@@ -1,56 +1,103 @@
 package hugo
 
 import (
-	"bytes"
+	"reflect"
+	"sort"
 	"strings"
 
-	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
 )
 
 type MDFile struct {
 	MetaData map[string]interface{}
 	Body     string
-	Format   string // "yaml" or "toml"
+	Format   string // "yaml", "toml" or "json"
+	EOL      string // The line ending of the file, "\n" or "\r\n"
+
+	// source is the front matter as it was read from disk. It is used to
+	// write untouched fields back exactly as they were.
+	source frontMatter
+}
+
+// frontMatter is a parsed front matter block that remembers the original
+// text of each top-level key.
+type frontMatter interface {
+	// keys returns the top-level keys in document order
+	keys() []string
+	// encode renders meta, reusing the original text of unchanged keys
+	encode(meta map[string]interface{}) (string, error)
 }
 
 // ParseMD parses the file content into MDFile struct
 func ParseMD(content string) (*MDFile, error) {
+	md, err := parseMD(content)
+	if err != nil {
+		return nil, err
+	}
+	md.EOL = "\n"
+	if i := strings.IndexByte(content, '\n'); i > 0 && content[i-1] == '\r' {
+		md.EOL = "\r\n"
+	}
+	return md, nil
+}
+
+func parseMD(content string) (*MDFile, error) {
 	// Check for TOML
 	if strings.HasPrefix(content, "+++") {
-		parts := strings.SplitN(content, "+++", 3)
-		if len(parts) < 3 {
+		fm, body, ok := splitFrontMatter(content, "+++")
+		if !ok {
 			// Empty front matter?? or broken
 			// Only header, no body?
 			return &MDFile{Body: content, Format: "toml", MetaData: make(map[string]interface{})}, nil
 		}
 
-		fm := make(map[string]interface{})
-		if _, err := toml.Decode(parts[1], &fm); err != nil {
+		doc, meta, err := parseTOML(fm)
+		if err != nil {
 			return nil, err
 		}
 		return &MDFile{
-			MetaData: fm,
-			Body:     strings.TrimPrefix(parts[2], "\n"),
+			MetaData: meta,
+			Body:     body,
 			Format:   "toml",
+			source:   doc,
 		}, nil
 	}
 
 	// Check for YAML
 	if strings.HasPrefix(content, "---") {
-		parts := strings.SplitN(content, "---", 3)
-		if len(parts) < 3 {
+		fm, body, ok := splitFrontMatter(content, "---")
+		if !ok {
 			return &MDFile{Body: content, Format: "yaml", MetaData: make(map[string]interface{})}, nil
 		}
 
-		fm := make(map[string]interface{})
-		if err := yaml.Unmarshal([]byte(parts[1]), &fm); err != nil {
+		doc, meta, err := parseYAML(fm)
+		if err != nil {
 			return nil, err
 		}
 		return &MDFile{
-			MetaData: fm,
-			Body:     strings.TrimPrefix(parts[2], "\n"),
+			MetaData: meta,
+			Body:     body,
 			Format:   "yaml",
+			source:   doc,
+		}, nil
+	}
+
+	// Check for JSON. Content can also start with a {{< shortcode >}},
+	// which is body, not front matter.
+	if strings.HasPrefix(content, "{") && !strings.HasPrefix(content, "{{") {
+		fm, body, err := splitJSONFrontMatter(content)
+		if err != nil {
+			return nil, err
+		}
+		doc, meta, err := parseJSON(fm)
+		if err != nil {
+			return nil, err
+		}
+		return &MDFile{
+			MetaData: meta,
+			Body:     body,
+			Format:   "json",
+			source:   doc,
 		}, nil
 	}
 
@@ -58,20 +105,163 @@ func ParseMD(content string) (*MDFile, error) {
 	return &MDFile{Body: content, Format: "yaml", MetaData: make(map[string]interface{})}, nil
 }
 
-// ToString reconstructs the file content
+// ToString reconstructs the file content, with the front matter in the
+// line endings of the file
 func (m *MDFile) ToString() (string, error) {
+	// Don't invent a front matter block for a plain Markdown file
+	if m.source == nil && len(m.MetaData) == 0 {
+		return m.Body, nil
+	}
+
+	fm, err := m.frontMatter()
+	if err != nil {
+		return "", err
+	}
+	if m.EOL == "\r\n" {
+		fm = strings.ReplaceAll(strings.ReplaceAll(fm, "\r\n", "\n"), "\n", "\r\n")
+	}
+	return fm + m.Body, nil
+}
+
+// frontMatter renders the front matter with its delimiters
+func (m *MDFile) frontMatter() (string, error) {
 	if m.Format == "toml" {
-		buf := new(bytes.Buffer)
-		if err := toml.NewEncoder(buf).Encode(m.MetaData); err != nil {
+		doc, ok := m.source.(*tomlDoc)
+		if !ok {
+			doc = &tomlDoc{}
+		}
+		fm, err := doc.encode(m.MetaData)
+		if err != nil {
 			return "", err
 		}
-		return "+++\n" + buf.String() + "+++\n" + m.Body, nil
+		return "+++\n" + fm + "+++\n", nil
+	}
+
+	if m.Format == "json" {
+		doc, ok := m.source.(*jsonDoc)
+		if !ok {
+			doc = &jsonDoc{}
+		}
+		fm, err := doc.encode(m.MetaData)
+		if err != nil {
+			return "", err
+		}
+		return fm + "\n", nil
 	}
 
 	// Default YAML
-	yamlBytes, err := yaml.Marshal(m.MetaData)
+	doc, ok := m.source.(*yamlDoc)
+	if !ok {
+		doc = &yamlDoc{indent: 2}
+	}
+	fm, err := doc.encode(m.MetaData)
 	if err != nil {
 		return "", err
 	}
-	return "---\n" + string(yamlBytes) + "---\n" + m.Body, nil
+	return "---\n" + fm + "---\n", nil
+}
+
+// Keys returns the front matter keys in the order they appear in the file,
+// followed by any keys added since it was read in sorted order.
+func (m *MDFile) Keys() []string {
+	var known []string
+	if m.source != nil {
+		known = m.source.keys()
+	}
+	return orderedKeys(known, m.MetaData)
+}
+
+// orderedKeys returns the keys of meta, keeping the order of known and
+// appending the rest sorted.
+func orderedKeys(known []string, meta map[string]interface{}) []string {
+	keys := make([]string, 0, len(meta))
+	seen := make(map[string]bool, len(meta))
+	for _, k := range known {
+		if _, ok := meta[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+
+	var added []string
+	for k := range meta {
+		if !seen[k] {
+			added = append(added, k)
+		}
+	}
+	sort.Strings(added)
+
+	return append(keys, added...)
+}
+
+// splitFrontMatter splits content into the text between the opening and
+// closing delim lines and the body that follows. ok is false when the
+// closing delimiter is missing.
+func splitFrontMatter(content, delim string) (fm, body string, ok bool) {
+	nl := strings.IndexByte(content, '\n')
+	if nl < 0 || strings.TrimRight(content[:nl], " \t\r") != delim {
+		return "", "", false
+	}
+	rest := content[nl+1:]
+
+	for off := 0; off < len(rest); {
+		end := strings.IndexByte(rest[off:], '\n')
+		line := rest[off:]
+		if end >= 0 {
+			line = rest[off : off+end]
+		}
+		if strings.TrimRight(line, " \t\r") == delim {
+			if end < 0 {
+				return rest[:off], "", true
+			}
+			return rest[:off], rest[off+end+1:], true
+		}
+		if end < 0 {
+			break
+		}
+		off += end + 1
+	}
+	return "", "", false
+}
+
+// splitLines splits text into lines, keeping their line endings
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// splitTrivia separates the trailing blank and comment lines from lines,
+// so that the comments above a key stay with that key.
+func splitTrivia(lines []string) (content, trivia []string) {
+	i := len(lines)
+	for i > 0 && isTrivia(lines[i-1]) {
+		i--
+	}
+	return lines[:i], lines[i:]
+}
+
+// isTrivia reports whether line is blank or a top-level comment
+func isTrivia(line string) bool {
+	return strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#")
+}
+
+// sameValue reports whether a and b would be written out the same way.
+// Editors may hand back a []string for a decoded []interface{} and so on,
+// so values are compared by their encoding rather than their Go type.
+func sameValue(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	ea, err := yaml.Marshal(a)
+	if err != nil {
+		return false
+	}
+	eb, err := yaml.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(ea) == string(eb)
 }
@@ -0,0 +1,238 @@
+package hugo
+
+import "testing"
+
+func TestParseMDJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		format  string
+		meta    int
+		body    string
+	}{
+		{
+			name:    "front matter",
+			content: "{\n  \"title\": \"Hello\",\n  \"draft\": true\n}\nBody\n",
+			format:  "json",
+			meta:    2,
+			body:    "Body\n",
+		},
+		{
+			name:    "shortcode",
+			content: "{{< figure src=\"a.png\" >}}\n\nBody\n",
+			format:  "yaml",
+			meta:    0,
+			body:    "{{< figure src=\"a.png\" >}}\n\nBody\n",
+		},
+		{
+			name:    "shortcode with percent",
+			content: "{{% note %}}Body{{% /note %}}\n",
+			format:  "yaml",
+			meta:    0,
+			body:    "{{% note %}}Body{{% /note %}}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md, err := ParseMD(tt.content)
+			if err != nil {
+				t.Fatalf("ParseMD: %v", err)
+			}
+			if md.Format != tt.format {
+				t.Errorf("format = %q, want %q", md.Format, tt.format)
+			}
+			if len(md.MetaData) != tt.meta {
+				t.Errorf("got %d keys, want %d", len(md.MetaData), tt.meta)
+			}
+			if md.Body != tt.body {
+				t.Errorf("body = %q, want %q", md.Body, tt.body)
+			}
+
+			out, err := md.ToString()
+			if err != nil {
+				t.Fatalf("ToString: %v", err)
+			}
+			if out != tt.content {
+				t.Errorf("round trip = %q, want %q", out, tt.content)
+			}
+		})
+	}
+}
+
+func TestParseMDBadJSON(t *testing.T) {
+	for _, content := range []string{
+		"{\n  \"title\": \"Hello\",\n}\nBody\n",
+		"{\n  \"title\": \"Hello\"\nBody\n",
+		"{ title: Hello }\nBody\n",
+	} {
+		if md, err := ParseMD(content); err == nil {
+			t.Errorf("ParseMD(%q) = %q with body %q, want an error", content, md.Format, md.Body)
+		}
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		edit    func(meta map[string]interface{})
+		want    string
+	}{
+		{
+			name:    "yaml",
+			content: "---\r\ntitle: a\r\n---\r\nBody\r\n",
+			edit:    func(meta map[string]interface{}) { meta["title"] = "b"; meta["weight"] = 3 },
+			want:    "---\r\ntitle: b\r\nweight: 3\r\n---\r\nBody\r\n",
+		},
+		{
+			name:    "toml",
+			content: "+++\r\ntitle = 'a'\r\n+++\r\nBody\r\n",
+			edit:    func(meta map[string]interface{}) { meta["weight"] = 3 },
+			want:    "+++\r\ntitle = 'a'\r\nweight = 3\r\n+++\r\nBody\r\n",
+		},
+		{
+			name:    "json",
+			content: "{\r\n  \"title\": \"a\"\r\n}\r\nBody\r\n",
+			edit:    func(meta map[string]interface{}) {},
+			want:    "{\r\n  \"title\": \"a\"\r\n}\r\nBody\r\n",
+		},
+		{
+			name:    "front matter added",
+			content: "Body\r\n",
+			edit:    func(meta map[string]interface{}) { meta["title"] = "a" },
+			want:    "---\r\ntitle: a\r\n---\r\nBody\r\n",
+		},
+		{
+			name:    "unix",
+			content: "---\ntitle: a\n---\nBody\n",
+			edit:    func(meta map[string]interface{}) { meta["title"] = "b" },
+			want:    "---\ntitle: b\n---\nBody\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md, err := ParseMD(tt.content)
+			if err != nil {
+				t.Fatalf("ParseMD: %v", err)
+			}
+			tt.edit(md.MetaData)
+			out, err := md.ToString()
+			if err != nil {
+				t.Fatalf("ToString: %v", err)
+			}
+			if out != tt.want {
+				t.Errorf("got %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditKeepsStyle(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		key     string
+		val     interface{}
+		want    string
+	}{
+		{
+			name:    "yaml list at the column of its key",
+			content: "---\ntags:\n- a\n- b\n---\n",
+			key:     "tags",
+			val:     []interface{}{"a", "c"},
+			want:    "---\ntags:\n- a\n- c\n---\n",
+		},
+		{
+			name:    "yaml indented list",
+			content: "---\ntags:\n  - a\n  - b\n---\n",
+			key:     "tags",
+			val:     []interface{}{"a", "c"},
+			want:    "---\ntags:\n  - a\n  - c\n---\n",
+		},
+		{
+			name:    "yaml list of records",
+			content: "---\nresources:\n- name: a\n  src: a.png\n- name: b\n  src: b.png\n---\n",
+			key:     "resources",
+			val: []interface{}{
+				map[string]interface{}{"name": "a", "src": "a.png"},
+				map[string]interface{}{"name": "b", "src": "c.png", "params": map[string]interface{}{"tags": []interface{}{"x"}}},
+			},
+			want: "---\nresources:\n- name: a\n  src: a.png\n- name: b\n  src: c.png\n  params:\n    tags:\n    - x\n---\n",
+		},
+		{
+			name:    "yaml block text is left as it is",
+			content: "---\ntags:\n- a\nnote: |\n  Steps:\n  one\n---\n",
+			key:     "note",
+			val:     "Steps:\n    - one\n",
+			want:    "---\ntags:\n- a\nnote: |\n  Steps:\n      - one\n---\n",
+		},
+		{
+			name:    "toml single quotes",
+			content: "+++\ntitle = 'Hi'\n+++\n",
+			key:     "title",
+			val:     "Ho",
+			want:    "+++\ntitle = 'Ho'\n+++\n",
+		},
+		{
+			name:    "toml single quotes in a list",
+			content: "+++\ntags = ['a', 'b'] # topics\n+++\n",
+			key:     "tags",
+			val:     []interface{}{"a", "c"},
+			want:    "+++\ntags = ['a', 'c'] # topics\n+++\n",
+		},
+		{
+			name:    "toml single quote in the text",
+			content: "+++\ntitle = 'Hi'\n+++\n",
+			key:     "title",
+			val:     "It's",
+			want:    "+++\ntitle = \"It's\"\n+++\n",
+		},
+		{
+			name:    "toml double quotes",
+			content: "+++\ntitle = \"Hi\" # 'quoted'\n+++\n",
+			key:     "title",
+			val:     "Ho",
+			want:    "+++\ntitle = \"Ho\" # 'quoted'\n+++\n",
+		},
+		{
+			name:    "json inline list",
+			content: "{\n  \"tags\": [\"a\", \"b\"]\n}\n",
+			key:     "tags",
+			val:     []interface{}{"a", "c"},
+			want:    "{\n  \"tags\": [\"a\", \"c\"]\n}\n",
+		},
+		{
+			name:    "json compact object",
+			content: "{\n  \"params\": {\"x\":1}\n}\n",
+			key:     "params",
+			val:     map[string]interface{}{"x": 2.0},
+			want:    "{\n  \"params\": {\"x\":2}\n}\n",
+		},
+		{
+			name:    "json inline list in an object",
+			content: "{\n  \"params\": {\n    \"z\": 1,\n    \"tags\": [\"a\"]\n  }\n}\n",
+			key:     "params",
+			val:     map[string]interface{}{"z": 2.0, "tags": []interface{}{"a"}, "new": true},
+			want:    "{\n  \"params\": {\n    \"z\": 2,\n    \"tags\": [\"a\"],\n    \"new\": true\n  }\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md, err := ParseMD(tt.content)
+			if err != nil {
+				t.Fatalf("ParseMD: %v", err)
+			}
+			md.MetaData[tt.key] = tt.val
+			out, err := md.ToString()
+			if err != nil {
+				t.Fatalf("ToString: %v", err)
+			}
+			if out != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", out, tt.want)
+			}
+		})
+	}
+}
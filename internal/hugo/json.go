@@ -0,0 +1,304 @@
+package hugo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonDoc is a JSON front matter object split into the original text of
+// each member.
+type jsonDoc struct {
+	members []jsonMember
+	close   string // whitespace before the closing brace
+}
+
+type jsonMember struct {
+	key  string
+	pre  string // whitespace before the key
+	text string // "key": value
+	raw  json.RawMessage
+	orig interface{}
+}
+
+// splitJSONFrontMatter splits content that starts with a JSON object into
+// the object and the body that follows it.
+func splitJSONFrontMatter(content string) (fm, body string, err error) {
+	dec := json.NewDecoder(strings.NewReader(content))
+	var obj json.RawMessage
+	if err := dec.Decode(&obj); err != nil {
+		return "", "", err
+	}
+	end := int(dec.InputOffset())
+	body = content[end:]
+	if strings.HasPrefix(body, "\r\n") {
+		body = body[1:]
+	}
+	return content[:end], strings.TrimPrefix(body, "\n"), nil
+}
+
+func parseJSON(fm string) (*jsonDoc, map[string]interface{}, error) {
+	meta := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(fm), &meta); err != nil {
+		return nil, nil, err
+	}
+
+	// A second copy, so in-place edits of nested values in meta are noticed
+	orig := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(fm), &orig); err != nil {
+		return nil, nil, err
+	}
+
+	doc := &jsonDoc{}
+	dec := json.NewDecoder(strings.NewReader(fm))
+	if _, err := dec.Token(); err != nil { // {
+		return nil, nil, err
+	}
+	prev := int(dec.InputOffset())
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("unexpected %v in front matter", tok)
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+		end := int(dec.InputOffset())
+
+		// Whatever lies between the previous member and this one is
+		// whitespace around a comma
+		gap := fm[prev:end]
+		start := strings.IndexByte(gap, '"')
+		pre := gap[:start]
+		if i := strings.LastIndexByte(pre, ','); i >= 0 {
+			pre = pre[i+1:]
+		}
+
+		doc.members = append(doc.members, jsonMember{
+			key:  key,
+			pre:  pre,
+			text: gap[start:],
+			raw:  raw,
+			orig: orig[key],
+		})
+		prev = end
+	}
+	doc.close = strings.TrimSuffix(fm[prev:], "}")
+
+	return doc, meta, nil
+}
+
+func (d *jsonDoc) keys() []string {
+	keys := make([]string, len(d.members))
+	for i, m := range d.members {
+		keys[i] = m.key
+	}
+	return keys
+}
+
+func (d *jsonDoc) encode(meta map[string]interface{}) (string, error) {
+	// New members are laid out like the last existing one
+	pre := "\n  "
+	if n := len(d.members); n > 0 {
+		pre = d.members[n-1].pre
+	}
+	indent := strings.TrimLeft(pre, "\r\n")
+
+	var parts []string
+	written := make(map[string]bool, len(meta))
+	for _, m := range d.members {
+		val, ok := meta[m.key]
+		if !ok || written[m.key] {
+			continue
+		}
+		written[m.key] = true
+
+		if sameValue(m.orig, val) {
+			parts = append(parts, m.pre+m.text)
+			continue
+		}
+		text, err := encodeJSONMember(m.key, val, m.raw, strings.TrimLeft(m.pre, "\r\n"))
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, m.pre+text)
+	}
+
+	for _, k := range orderedKeys(nil, meta) {
+		if written[k] {
+			continue
+		}
+		text, err := encodeJSONMember(k, meta[k], nil, indent)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, pre+text)
+	}
+
+	close := d.close
+	if len(d.members) == 0 && len(parts) > 0 {
+		close = "\n"
+	}
+	return "{" + strings.Join(parts, ",") + close + "}", nil
+}
+
+// encodeJSONMember renders "key": value, with nested values indented
+// relative to indent and laid out like orig, the value as written before.
+func encodeJSONMember(key string, val interface{}, orig json.RawMessage, indent string) (string, error) {
+	unit := "  "
+	if strings.HasPrefix(indent, "\t") {
+		unit = "\t"
+	}
+	keyText, err := marshalJSON(key)
+	if err != nil {
+		return "", err
+	}
+	text, err := encodeJSONValue(val, orig, indent, unit)
+	if err != nil {
+		return "", err
+	}
+	return keyText + ": " + text, nil
+}
+
+// encodeJSONValue renders val with one member or item a line, indented by
+// unit from indent. Values orig wrote on a single line stay on one, and
+// the members and items that were there keep their order and layout.
+func encodeJSONValue(val interface{}, orig json.RawMessage, indent, unit string) (string, error) {
+	if orig != nil && !bytes.Contains(orig, []byte("\n")) {
+		text, err := marshalJSON(val)
+		if err != nil {
+			return "", err
+		}
+		compact := new(bytes.Buffer)
+		if json.Compact(compact, orig) == nil && !bytes.Equal(compact.Bytes(), orig) {
+			text = spaceJSON(text)
+		}
+		return text, nil
+	}
+
+	inner := indent + unit
+	switch v := val.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return "{}", nil
+		}
+		origMembers := make(map[string]json.RawMessage)
+		json.Unmarshal(orig, &origMembers)
+
+		keys := jsonKeys(orig)
+		for _, k := range sortedMapKeys(v) {
+			if _, ok := origMembers[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		var parts []string
+		for _, k := range keys {
+			item, ok := v[k]
+			if !ok {
+				continue
+			}
+			text, err := encodeJSONMember(k, item, origMembers[k], inner)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, inner+text)
+		}
+		return "{\n" + strings.Join(parts, ",\n") + "\n" + indent + "}", nil
+	case []interface{}:
+		if len(v) == 0 {
+			return "[]", nil
+		}
+		var origItems []json.RawMessage
+		json.Unmarshal(orig, &origItems)
+		origValues := make([]interface{}, len(origItems))
+		for i, item := range origItems {
+			json.Unmarshal(item, &origValues[i])
+		}
+
+		match := matchValues(v, origValues)
+		parts := make([]string, len(v))
+		for i, item := range v {
+			var origItem json.RawMessage
+			if match[i] >= 0 {
+				origItem = origItems[match[i]]
+			}
+			text, err := encodeJSONValue(item, origItem, inner, unit)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = inner + text
+		}
+		return "[\n" + strings.Join(parts, ",\n") + "\n" + indent + "]", nil
+	}
+
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(indent, unit)
+	if err := enc.Encode(val); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func marshalJSON(val interface{}) (string, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(val); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// jsonKeys returns the keys of an encoded object in the order they are
+// written
+func jsonKeys(raw json.RawMessage) []string {
+	var keys []string
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return keys
+		}
+		key, _ := tok.(string)
+		keys = append(keys, key)
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return keys
+		}
+	}
+	return keys
+}
+
+// spaceJSON puts a space after the commas and colons of compact JSON, as
+// in ["a", "b"]
+func spaceJSON(text string) string {
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		b.WriteByte(c)
+		switch {
+		case inString && c == '\\':
+			i++
+			b.WriteByte(text[i])
+		case c == '"':
+			inString = !inString
+		case !inString && (c == ',' || c == ':'):
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
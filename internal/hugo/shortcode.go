@@ -0,0 +1,268 @@
+package hugo
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Shortcode is a shortcode template of the site, a theme or Hugo itself,
+// with the parameters it reads
+type Shortcode struct {
+	Name   string // e.g. "youtube", or "blog/note" for layouts/shortcodes/blog/note.html
+	Path   string // empty for built-ins
+	Theme  string // the theme it comes from, empty for the site
+	Params []ShortcodeParam
+	Inner  bool // it uses .Inner, so calls have a closing tag
+}
+
+// ShortcodeParam is a parameter read with .Get, by name or by position
+type ShortcodeParam struct {
+	Name     string // empty for positional parameters of templates
+	Position int    // -1 for named parameters
+}
+
+// Label describes the parameter in a form
+func (p ShortcodeParam) Label() string {
+	switch {
+	case p.Position < 0:
+		return p.Name
+	case p.Name != "":
+		return fmt.Sprintf("%s (#%d)", p.Name, p.Position+1)
+	}
+	return fmt.Sprintf("Parameter #%d", p.Position+1)
+}
+
+// Label describes the shortcode for a picker
+func (s Shortcode) Label() string {
+	switch {
+	case s.Theme != "":
+		return s.Name + " · " + s.Theme
+	case s.Path == "":
+		return s.Name + " (built-in)"
+	}
+	return s.Name
+}
+
+// Named reports whether the shortcode reads parameters by name
+func (s Shortcode) Named() bool {
+	for _, p := range s.Params {
+		if p.Position < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Positional reports whether the shortcode reads parameters by position.
+// A template can read both kinds, e.g. .Get "src" falling back on .Get 0,
+// but Hugo doesn't allow mixing them in one call.
+func (s Shortcode) Positional() bool {
+	for _, p := range s.Params {
+		if p.Position >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Call returns a call of the shortcode with the given parameter values,
+// keyed by Label, passing those read by position or those read by name.
+// Empty values are left out, and so is everything after the first empty
+// positional value.
+func (s Shortcode) Call(values map[string]string, inner string, positional bool) string {
+	var b strings.Builder
+	b.WriteString("{{< " + s.Name)
+	for _, p := range s.Params {
+		v := values[p.Label()]
+		if positional != (p.Position >= 0) {
+			continue
+		}
+		if v == "" {
+			if positional {
+				break
+			}
+			continue
+		}
+		b.WriteString(" ")
+		if !positional {
+			b.WriteString(p.Name + "=")
+		}
+		b.WriteString(quoteShortcodeValue(v))
+	}
+	b.WriteString(" >}}")
+	if s.Inner {
+		b.WriteString(inner)
+		if strings.Contains(inner, "\n") && !strings.HasSuffix(inner, "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString("{{< /" + s.Name + " >}}")
+	}
+	return b.String()
+}
+
+func quoteShortcodeValue(v string) string {
+	if strings.Contains(v, `"`) && !strings.Contains(v, "`") {
+		return "`" + v + "`"
+	}
+	return `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+}
+
+// builtinShortcodes are the shortcodes that come with Hugo
+var builtinShortcodes = []Shortcode{
+	{Name: "details", Inner: true, Params: named("summary", "open", "class", "name", "title")},
+	{Name: "figure", Params: named("src", "alt", "title", "caption", "link", "target", "rel", "class", "width", "height", "loading", "attr", "attrlink")},
+	{Name: "gist", Params: positional("user", "id", "file")},
+	{Name: "highlight", Inner: true, Params: positional("lang", "options")},
+	{Name: "instagram", Params: positional("id")},
+	{Name: "param", Params: positional("name")},
+	{Name: "qr", Params: named("text", "level", "scale", "targetDir", "alt", "class", "id", "title", "loading")},
+	{Name: "ref", Params: positional("path")},
+	{Name: "relref", Params: positional("path")},
+	{Name: "vimeo", Params: named("id", "class", "title", "allowFullScreen", "loading")},
+	{Name: "x", Params: named("user", "id")},
+	{Name: "youtube", Params: named("id", "title", "class", "start", "end", "autoplay", "controls", "mute", "loop", "allowFullScreen", "loading")},
+}
+
+func named(names ...string) []ShortcodeParam {
+	params := make([]ShortcodeParam, len(names))
+	for i, name := range names {
+		params[i] = ShortcodeParam{Name: name, Position: -1}
+	}
+	return params
+}
+
+func positional(names ...string) []ShortcodeParam {
+	params := make([]ShortcodeParam, len(names))
+	for i, name := range names {
+		params[i] = ShortcodeParam{Name: name, Position: i}
+	}
+	return params
+}
+
+// FindShortcodes lists the shortcodes of the site, then of each theme
+// directory, then Hugo's built-ins. A shortcode hides those of the same
+// name that come after it, the way Hugo looks them up.
+func FindShortcodes(siteDir string, themeDirs []string) []Shortcode {
+	shortcodes := readShortcodes(siteDir, "")
+	for _, dir := range themeDirs {
+		shortcodes = append(shortcodes, readShortcodes(dir, filepath.Base(dir))...)
+	}
+	shortcodes = append(shortcodes, builtinShortcodes...)
+
+	seen := make(map[string]bool)
+	var out []Shortcode
+	for _, s := range shortcodes {
+		if !seen[s.Name] {
+			seen[s.Name] = true
+			out = append(out, s)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// readShortcodes reads layouts/shortcodes, or layouts/_shortcodes of newer
+// Hugo versions, below dir. Variants for a language or output format, like
+// note.en.html, add to the parameters of the shortcode.
+func readShortcodes(dir, theme string) []Shortcode {
+	var shortcodes []Shortcode
+	index := make(map[string]int)
+	for _, sub := range []string{"_shortcodes", "shortcodes"} {
+		root := filepath.Join(dir, "layouts", sub)
+		filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || strings.HasPrefix(d.Name(), ".") {
+				if d != nil && d.IsDir() && p != root {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			content, err := os.ReadFile(p)
+			if err != nil {
+				return nil
+			}
+
+			rel, _ := filepath.Rel(root, p)
+			name := filepath.ToSlash(rel)
+			base := filepath.Base(name)
+			name = strings.TrimSuffix(name, base) + strings.Split(base, ".")[0]
+
+			s := ParseShortcode(name, string(content))
+			s.Path = p
+			s.Theme = theme
+			if i, ok := index[name]; ok {
+				shortcodes[i] = mergeShortcodes(shortcodes[i], s)
+				return nil
+			}
+			index[name] = len(shortcodes)
+			shortcodes = append(shortcodes, s)
+			return nil
+		})
+	}
+	return shortcodes
+}
+
+var (
+	namedGet      = regexp.MustCompile("(?:\\.Get|index\\s+\\.Params)\\s+[\"`]([^\"`]+)[\"`]")
+	positionalGet = regexp.MustCompile(`(?:\.Get|index\s+\.Params)\s+(\d+)`)
+	paramsField   = regexp.MustCompile(`\.Params\.([A-Za-z_]\w*)`)
+	innerUse      = regexp.MustCompile(`\.Inner(?:Deindent)?\b`)
+)
+
+// ParseShortcode reads the parameters a shortcode template uses, in the
+// order they first appear
+func ParseShortcode(name, template string) Shortcode {
+	s := Shortcode{Name: name, Inner: innerUse.MatchString(template)}
+
+	type found struct {
+		at    int
+		param ShortcodeParam
+	}
+	var params []found
+	for _, m := range namedGet.FindAllStringSubmatchIndex(template, -1) {
+		params = append(params, found{m[0], ShortcodeParam{Name: template[m[2]:m[3]], Position: -1}})
+	}
+	for _, m := range paramsField.FindAllStringSubmatchIndex(template, -1) {
+		params = append(params, found{m[0], ShortcodeParam{Name: template[m[2]:m[3]], Position: -1}})
+	}
+	var positions []ShortcodeParam
+	for _, m := range positionalGet.FindAllStringSubmatchIndex(template, -1) {
+		n, _ := strconv.Atoi(template[m[2]:m[3]])
+		positions = append(positions, ShortcodeParam{Position: n})
+	}
+	sort.SliceStable(params, func(i, j int) bool { return params[i].at < params[j].at })
+
+	for _, f := range params {
+		s.Params = addParam(s.Params, f.param)
+	}
+	sort.SliceStable(positions, func(i, j int) bool { return positions[i].Position < positions[j].Position })
+	for _, p := range positions {
+		s.Params = addParam(s.Params, p)
+	}
+	return s
+}
+
+func mergeShortcodes(a, b Shortcode) Shortcode {
+	for _, p := range b.Params {
+		a.Params = addParam(a.Params, p)
+	}
+	a.Inner = a.Inner || b.Inner
+	return a
+}
+
+func addParam(params []ShortcodeParam, p ShortcodeParam) []ShortcodeParam {
+	for _, q := range params {
+		if q.Position == p.Position && strings.EqualFold(q.Name, p.Name) {
+			return params
+		}
+	}
+	return append(params, p)
+}
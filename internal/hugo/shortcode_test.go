@@ -0,0 +1,20 @@
+package hugo
+
+import "testing"
+
+func TestParseShortcodeMixed(t *testing.T) {
+	tmpl := `{{ $src := .Get "src" | default (.Get 0) }}
+<img src="{{ $src }}" alt="{{ .Get "alt" | default (.Get 1) }}">`
+	s := ParseShortcode("img", tmpl)
+	if !s.Named() || !s.Positional() {
+		t.Fatalf("params = %+v, want both kinds", s.Params)
+	}
+
+	values := map[string]string{"src": "a.png", "alt": "A", "Parameter #1": "b.png", "Parameter #2": ""}
+	if got, want := s.Call(values, "", false), `{{< img src="a.png" alt="A" >}}`; got != want {
+		t.Errorf("named call = %s, want %s", got, want)
+	}
+	if got, want := s.Call(values, "", true), `{{< img "b.png" >}}`; got != want {
+		t.Errorf("positional call = %s, want %s", got, want)
+	}
+}
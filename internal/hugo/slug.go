@@ -0,0 +1,95 @@
+package hugo
+
+import (
+	"strings"
+	"unicode"
+)
+
+// transliterations write letters of Arabic script and accented Latin
+// letters in plain ASCII. Arabic script is written without short vowels,
+// as it is spelled.
+var transliterations = map[rune]string{
+	// Arabic
+	'ا': "a", 'أ': "a", 'إ': "i", 'آ': "a", 'ٱ': "a", 'ء': "", 'ؤ': "u", 'ئ': "i",
+	'ب': "b", 'ت': "t", 'ث': "th", 'ج': "j", 'ح': "h", 'خ': "kh",
+	'د': "d", 'ذ': "dh", 'ر': "r", 'ز': "z", 'س': "s", 'ش': "sh",
+	'ص': "s", 'ض': "d", 'ط': "t", 'ظ': "z", 'ع': "", 'غ': "gh",
+	'ف': "f", 'ق': "q", 'ك': "k", 'ل': "l", 'م': "m", 'ن': "n",
+	'ه': "h", 'ة': "a", 'و': "w", 'ي': "y", 'ى': "a",
+	// Persian
+	'پ': "p", 'چ': "ch", 'ژ': "zh", 'گ': "g", 'ک': "k", 'ی': "y", 'ۀ': "e",
+	// Urdu
+	'ٹ': "t", 'ڈ': "d", 'ڑ': "r", 'ں': "n", 'ہ': "h", 'ھ': "h", 'ۃ': "a",
+	'ے': "e", 'ۓ': "e",
+	// Latin
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae", 'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i",
+	'ł': "l", 'ľ': "l", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'œ': "oe",
+	'ř': "r", 'ś': "s", 'š': "s", 'ş': "s", 'ș': "s", 'ß': "ss",
+	'ť': "t", 'ţ': "t", 'ț': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// Slugify turns a title into a file name and slug: lower case words of
+// letters and digits joined by dashes. Letters of every script are kept,
+// unless transliterate is set, which writes those it knows in ASCII.
+// Arabic vowel marks and the tatweel are always dropped, and digits are
+// written in ASCII when transliterating.
+func Slugify(text string, transliterate bool) string {
+	var b strings.Builder
+	dash := false
+	word := true // At the start of a word, for the Arabic article
+	runes := []rune(strings.ToLower(text))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case isArabicMark(r) || r == 'ـ' || r == '‌':
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			if !transliterate {
+				b.WriteRune(r)
+				break
+			}
+			if word && r == 'ا' && i+1 < len(runes) && runes[i+1] == 'ل' && i+2 < len(runes) && unicode.IsLetter(runes[i+2]) {
+				b.WriteString("al-")
+				i++
+				break
+			}
+			if s, ok := transliterations[r]; ok {
+				b.WriteString(s)
+			} else if unicode.IsDigit(r) && r > unicode.MaxASCII {
+				b.WriteRune('0' + digitValue(r))
+			} else {
+				b.WriteRune(r)
+			}
+		default:
+			dash = true
+		}
+		word = dash
+	}
+	return b.String()
+}
+
+// isArabicMark reports whether r is a vowel or Quranic mark of Arabic
+// script, left out of slugs
+func isArabicMark(r rune) bool {
+	return (r >= 0x064B && r <= 0x065F) || r == 0x0670 || (r >= 0x06D6 && r <= 0x06ED)
+}
+
+// digitValue returns the value of a decimal digit of any script. Digits
+// come in runs of ten from zero, some runs right after another.
+func digitValue(r rune) rune {
+	start := r
+	for unicode.IsDigit(start - 1) {
+		start--
+	}
+	return (r - start) % 10
+}
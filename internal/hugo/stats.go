@@ -0,0 +1,109 @@
+package hugo
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Where the summary of a page comes from
+const (
+	SummaryFrontMatter = "front matter"
+	SummaryManual      = "<!--more--> divider"
+	SummaryAutomatic   = "automatic"
+)
+
+// moreDivider is where a manual summary ends
+const moreDivider = "<!--more-->"
+
+var (
+	mdShortcode = regexp.MustCompile(`\{\{[<%].*?[>%]\}\}`)
+	mdImage     = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	mdLinkText  = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	htmlTag     = regexp.MustCompile(`<[^>]+>`)
+	mdMarks     = regexp.MustCompile("(?m)^\\s{0,3}(#{1,6}\\s|>\\s?|[-*+]\\s|\\d+[.)]\\s)|[*_`~]+")
+)
+
+// PlainText returns the text a reader sees of markdown, close to what Hugo
+// gets by stripping the rendered HTML. The output of shortcodes is
+// unknown, so they are left out.
+func PlainText(markdown string) string {
+	text := mdShortcode.ReplaceAllString(markdown, " ")
+	text = mdImage.ReplaceAllString(text, " ")
+	text = mdLinkText.ReplaceAllString(text, "$1")
+	text = htmlTag.ReplaceAllString(text, " ")
+	text = mdMarks.ReplaceAllString(text, " ")
+	return text
+}
+
+// IsCJK reports whether r is written without spaces between words
+func IsCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// HasCJK reports whether text has Chinese, Japanese or Korean in it
+func HasCJK(text string) bool {
+	for _, r := range text {
+		if IsCJK(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// CountWords counts the words of plain text the way Hugo does: each run
+// of text between spaces is a word, e.g. "e.g." or a URL, and each CJK
+// character is a word of its own.
+func CountWords(text string) int {
+	n := 0
+	for _, field := range strings.Fields(text) {
+		other := false // Text other than CJK, such as a number before a 年
+		for _, r := range field {
+			if IsCJK(r) {
+				n++
+			} else if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				other = true
+			}
+		}
+		if other || !HasCJK(field) {
+			n++
+		}
+	}
+	return n
+}
+
+// ReadingTime returns the minutes Hugo estimates reading takes: 213 words
+// a minute, or 501 for CJK, rounded up
+func ReadingTime(words int, cjk bool) int {
+	if cjk {
+		return (words + 500) / 501
+	}
+	return (words + 212) / 213
+}
+
+// Summary returns the summary of a page the way Hugo makes it: the content
+// before <!--more-->, else the summary front matter, else whole paragraphs
+// until there are at least length words. truncated reports whether the
+// content goes on after it.
+func Summary(body, frontMatter string, length int) (summary, source string, truncated bool) {
+	if before, after, ok := strings.Cut(body, moreDivider); ok {
+		return strings.TrimSpace(before), SummaryManual, strings.TrimSpace(after) != ""
+	}
+	if strings.TrimSpace(frontMatter) != "" {
+		return frontMatter, SummaryFrontMatter, true
+	}
+
+	blocks := SplitBlocks(body)
+	words := 0
+	for i, b := range blocks {
+		words += CountWords(PlainText(b.Text))
+		if words >= length {
+			texts := make([]string, i+1)
+			for j := range texts {
+				texts[j] = blocks[j].Text
+			}
+			return strings.TrimSpace(JoinBlocks(texts)), SummaryAutomatic, i < len(blocks)-1
+		}
+	}
+	return strings.TrimSpace(body), SummaryAutomatic, false
+}
@@ -0,0 +1,26 @@
+package hugo
+
+import "testing"
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"Hello, world!", 2},
+		{"e.g. 3.5 apples", 3},
+		{"see https://gohugo.io/content-management/ or foo/bar", 4},
+		{"well-known don't", 2},
+		{"a — b", 3},
+		{"مرحبا، بالعالم", 2},
+		{"你好世界", 4},
+		{"你好，世界", 4},
+		{"Hugo是 静态", 4},
+	}
+	for _, tt := range tests {
+		if got := CountWords(tt.text); got != tt.want {
+			t.Errorf("CountWords(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,965 @@
+package hugo
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// tomlDoc is a TOML document split into its table headers and key/value
+// statements, so that edits only rewrite the lines that changed.
+type tomlDoc struct {
+	blocks []tomlBlock
+	tail   []string // blank and comment lines after the last block
+	orig   map[string]interface{}
+}
+
+type tomlBlock struct {
+	lead   []string // blank and comment lines above the block
+	text   []string
+	header bool     // a [table] or [[array]] line rather than a key/value
+	array  []string // the array of tables the block is part of, if any
+	indent string   // statements: the indentation of the key
+	path   []string // the table of a header, the full key of a statement
+	key    string   // statements: the key as written, e.g. a.b or "my key"
+	table  []string // statements: the table they are in
+}
+
+// top returns the top-level key the block belongs to
+func (b *tomlBlock) top() string {
+	return b.path[0]
+}
+
+// root reports whether the block is a key/value outside of any table
+func (b *tomlBlock) root() bool {
+	return !b.header && len(b.table) == 0
+}
+
+func parseTOML(fm string) (*tomlDoc, map[string]interface{}, error) {
+	meta := make(map[string]interface{})
+	if _, err := toml.Decode(fm, &meta); err != nil {
+		return nil, nil, err
+	}
+
+	// A second copy, so in-place edits of nested values in meta are noticed
+	orig := make(map[string]interface{})
+	if _, err := toml.Decode(fm, &orig); err != nil {
+		return nil, nil, err
+	}
+
+	doc := &tomlDoc{orig: orig}
+	lines := splitLines(fm)
+
+	var table []string
+	var arrays [][]string // paths of the arrays of tables seen so far
+	inArray := func(path []string) []string {
+		for _, a := range arrays {
+			if hasPathPrefix(path, a) {
+				return a
+			}
+		}
+		return nil
+	}
+
+	var lead []string
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			lead = append(lead, lines[i])
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "[") {
+			array := strings.HasPrefix(trimmed, "[[")
+			path, _ := parseTOMLKey(strings.TrimLeft(trimmed, "["))
+			if len(path) == 0 {
+				return nil, nil, fmt.Errorf("bad table header at line %d", i+1)
+			}
+			if array {
+				arrays = append(arrays, path)
+			}
+			doc.blocks = append(doc.blocks, tomlBlock{
+				lead:   lead,
+				text:   []string{lines[i]},
+				header: true,
+				array:  inArray(path),
+				path:   path,
+			})
+			table = path
+			lead = nil
+			continue
+		}
+
+		// A key/value, which may run over several lines (arrays, """strings""")
+		end, err := tomlStatementEnd(lines, i)
+		if err != nil {
+			return nil, nil, err
+		}
+		keyPath, rest := parseTOMLKey(trimmed)
+		if len(keyPath) == 0 || !strings.HasPrefix(strings.TrimSpace(rest), "=") {
+			return nil, nil, fmt.Errorf("bad key at line %d", i+1)
+		}
+		path := append(append([]string{}, table...), keyPath...)
+		doc.blocks = append(doc.blocks, tomlBlock{
+			lead:   lead,
+			text:   lines[i:end],
+			array:  inArray(path),
+			indent: lines[i][:len(lines[i])-len(strings.TrimLeft(lines[i], " \t"))],
+			path:   path,
+			key:    strings.TrimSpace(trimmed[:len(trimmed)-len(rest)]),
+			table:  table,
+		})
+		lead = nil
+		i = end - 1
+	}
+	doc.tail = lead
+
+	return doc, meta, nil
+}
+
+// rewrite renders a key/value statement with a new value, keeping how the
+// key is written, its comment and whether its strings are in single quotes
+func (b *tomlBlock) rewrite(val interface{}) (string, error) {
+	text, err := encodeTOMLInline(val)
+	if err != nil {
+		return "", err
+	}
+	if b.literal() {
+		text = literalStrings(text)
+	}
+	return b.indent + b.key + " = " + text + tomlComment(b.text) + "\n", nil
+}
+
+// literal reports whether the first string of a statement's value is a
+// 'literal string'
+func (b *tomlBlock) literal() bool {
+	text := strings.Join(b.text, "")
+	_, value, _ := strings.Cut(text[len(b.indent)+len(b.key):], "=")
+	for _, r := range value {
+		switch r {
+		case '\'':
+			return true
+		case '"', '#':
+			return false
+		}
+	}
+	return false
+}
+
+// literalStrings writes the "basic strings" of an encoded value in single
+// quotes, unless they need escapes or hold a single quote
+func literalStrings(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '"' {
+			b.WriteByte(text[i])
+			continue
+		}
+		end := i + 1
+		for end < len(text) && text[end] != '"' {
+			if text[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(text) {
+			b.WriteString(text[i:])
+			break
+		}
+		if str := text[i+1 : end]; !strings.ContainsAny(str, "\\'") {
+			b.WriteString("'" + str + "'")
+		} else {
+			b.WriteString(text[i : end+1])
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// tomlStatementEnd returns the index of the line after the key/value that
+// starts at lines[start].
+func tomlStatementEnd(lines []string, start int) (int, error) {
+	var stmt strings.Builder
+	for end := start; end < len(lines); end++ {
+		stmt.WriteString(lines[end])
+		var v map[string]interface{}
+		if _, err := toml.Decode(stmt.String(), &v); err == nil {
+			return end + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated value at line %d", start+1)
+}
+
+// parseTOMLKey parses the dotted key at the start of s, returning its
+// parts and the text after it.
+func parseTOMLKey(s string) ([]string, string) {
+	var parts []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return parts, s
+		}
+
+		switch s[0] {
+		case '"':
+			end := 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, s
+			}
+			part, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				part = s[1:end]
+			}
+			parts = append(parts, part)
+			s = s[end+1:]
+		case '\'':
+			end := strings.IndexByte(s[1:], '\'')
+			if end < 0 {
+				return nil, s
+			}
+			parts = append(parts, s[1:end+1])
+			s = s[end+2:]
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool { return !isBareKeyChar(r) })
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return parts, s
+			}
+			parts = append(parts, s[:end])
+			s = s[end:]
+		}
+
+		rest := strings.TrimLeft(s, " \t")
+		if !strings.HasPrefix(rest, ".") {
+			return parts, s
+		}
+		s = rest[1:]
+	}
+}
+
+func isBareKeyChar(r rune) bool {
+	return r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func (d *tomlDoc) keys() []string {
+	keys := make([]string, 0, len(d.blocks))
+	seen := make(map[string]bool, len(d.blocks))
+	for i := range d.blocks {
+		if k := d.blocks[i].top(); !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	return keys
+}
+
+func (d *tomlDoc) encode(meta map[string]interface{}) (string, error) {
+	// Work out what happens to each block: kept, dropped or rewritten
+	out := make([]string, len(d.blocks))
+	extra := make([]string, len(d.blocks)) // tables added after a block
+	var values, tables strings.Builder
+
+	for _, key := range d.keys() {
+		var idx []int
+		for i := range d.blocks {
+			if d.blocks[i].top() == key {
+				idx = append(idx, i)
+			}
+		}
+
+		val, ok := meta[key]
+		switch {
+		case !ok:
+			// Removed, drop it together with the comments above it
+		case sameValue(d.orig[key], val):
+			for _, i := range idx {
+				out[i] = strings.Join(d.blocks[i].lead, "") + strings.Join(d.blocks[i].text, "")
+			}
+		default:
+			if first := &d.blocks[idx[0]]; len(idx) == 1 && first.root() && !isTOMLTableArray(val) {
+				if _, isMap := val.(map[string]interface{}); !isMap {
+					text, err := first.rewrite(val)
+					if err != nil {
+						return "", err
+					}
+					out[idx[0]] = strings.Join(first.lead, "") + text
+					break
+				}
+			}
+			if array := d.blocks[idx[0]].array; len(array) == 1 {
+				// An array of tables such as [[resources]]
+				if text, ok := d.spliceArray(array, idx, val); ok {
+					out[idx[0]] = text
+					break
+				}
+			}
+			if d.splice(key, idx, meta, out, extra) {
+				break
+			}
+
+			// Rewrite the whole key in place of its first block
+			text, err := encodeTOMLKey(key, val)
+			if err != nil {
+				return "", err
+			}
+			first := &d.blocks[idx[0]]
+			if isTOMLTable(text) {
+				extra[idx[0]] = strings.Join(first.lead, "") + text
+			} else {
+				out[idx[0]] = strings.Join(first.lead, "") + text
+				if !first.root() {
+					// Was a table, the value now belongs with the root keys
+					values.WriteString(out[idx[0]])
+					out[idx[0]] = ""
+				}
+			}
+		}
+	}
+
+	// Plain key/values must come before the first table. A blank line goes
+	// between them unless the first table was already there.
+	separate := true
+	for i := range d.blocks {
+		if d.blocks[i].root() {
+			values.WriteString(out[i])
+		} else {
+			if tables.Len() == 0 && out[i] != "" {
+				separate = false
+			}
+			tables.WriteString(out[i])
+		}
+		if extra[i] != "" {
+			writeTable(&tables, extra[i])
+		}
+	}
+
+	for _, k := range orderedKeys(d.keys(), meta) {
+		if _, existed := d.orig[k]; existed {
+			continue
+		}
+		text, err := encodeTOMLKey(k, meta[k])
+		if err != nil {
+			return "", err
+		}
+		if isTOMLTable(text) {
+			writeTable(&tables, text)
+		} else {
+			values.WriteString(text)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(values.String())
+	if separate && values.Len() > 0 && tables.Len() > 0 && !strings.HasPrefix(tables.String(), "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(tables.String())
+	writeLines(&b, d.tail)
+	return b.String(), nil
+}
+
+// splice rewrites only the statements of key that changed, adding new
+// keys to the table they belong in. It fills out and extra for the blocks
+// in idx, and reports false when the edit can't be expressed that way,
+// e.g. when a value turns into a table.
+func (d *tomlDoc) splice(key string, idx []int, meta map[string]interface{}, out, extra []string) bool {
+	top, ok := meta[key].(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	res := make(map[int]string, len(idx))
+	covered := make(map[string]bool)
+	// Where keys of a table can be added: after its last block, with the
+	// dotted prefix its keys are written with
+	type insertAt struct {
+		block  int
+		prefix string
+	}
+	tableEnd := make(map[string]insertAt)
+	for _, i := range idx {
+		b := &d.blocks[i]
+		if b.array != nil {
+			covered[pathKey(b.array)] = true
+			continue
+		}
+		covered[pathKey(b.path)] = true
+		if b.header {
+			tableEnd[pathKey(b.path)] = insertAt{block: i}
+			continue
+		}
+		parent := b.path[:len(b.path)-1]
+		prefix := ""
+		for _, p := range parent[len(b.table):] {
+			prefix += tomlKeyText(p) + "."
+		}
+		if len(parent) > 0 {
+			tableEnd[pathKey(parent)] = insertAt{block: i, prefix: prefix}
+		}
+	}
+
+	indent := d.tableIndent()
+	arrays := make(map[string]bool) // arrays of tables already written
+	for _, i := range idx {
+		b := &d.blocks[i]
+		lead := strings.Join(b.lead, "")
+
+		if b.array != nil {
+			// Arrays of tables are written item by item, in place of the
+			// first block
+			if arrays[pathKey(b.array)] {
+				continue
+			}
+			arrays[pathKey(b.array)] = true
+			newVal, ok := getPath(meta, b.array)
+			if !ok {
+				continue // removed
+			}
+			var blocks []int
+			for _, j := range idx {
+				if d.blocks[j].array != nil && pathKey(d.blocks[j].array) == pathKey(b.array) {
+					blocks = append(blocks, j)
+				}
+			}
+			text, ok := d.spliceArray(b.array, blocks, newVal)
+			if !ok {
+				return false
+			}
+			res[i] = text
+			continue
+		}
+
+		newVal, ok := getPath(meta, b.path)
+		if !ok {
+			continue // removed
+		}
+		if b.header {
+			if _, isMap := newVal.(map[string]interface{}); !isMap {
+				return false
+			}
+			res[i] = lead + strings.Join(b.text, "")
+			continue
+		}
+
+		origVal, _ := getPath(d.orig, b.path)
+		if sameValue(origVal, newVal) {
+			res[i] = lead + strings.Join(b.text, "")
+			continue
+		}
+		_, wasMap := origVal.(map[string]interface{})
+		if _, isMap := newVal.(map[string]interface{}); isMap && !wasMap {
+			return false
+		}
+		text, err := b.rewrite(newVal)
+		if err != nil {
+			return false
+		}
+		res[i] = lead + text
+	}
+
+	// Keys that didn't exist before go at the end of their table, new
+	// tables after the last block of the key
+	added := make(map[int]string)
+	var newTables []string
+	var walk func(path []string, m map[string]interface{}) bool
+	walk = func(path []string, m map[string]interface{}) bool {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			child := append(append([]string{}, path...), k)
+			cm, isMap := m[k].(map[string]interface{})
+
+			if covered[pathKey(child)] {
+				if isMap && d.isTable(idx, child) && !walk(child, cm) {
+					return false
+				}
+				continue
+			}
+			if d.hasBlockBelow(idx, child) {
+				// Only defined by deeper headers, e.g. [a.b.c] for a.b
+				if !isMap || !walk(child, cm) {
+					return false
+				}
+				continue
+			}
+
+			if isMap {
+				text, err := encodeTOMLTable(child, cm)
+				if err != nil {
+					return false
+				}
+				newTables = append(newTables, indentTOML(text, indent))
+				continue
+			}
+			at, ok := tableEnd[pathKey(path)]
+			if !ok {
+				return false
+			}
+			if isTOMLTableArray(m[k]) {
+				text, err := encodeTOMLTableArray(child, m[k])
+				if err != nil {
+					return false
+				}
+				newTables = append(newTables, indentTOML(text, indent))
+				continue
+			}
+			text, err := encodeTOMLInline(m[k])
+			if err != nil {
+				return false
+			}
+			keyIndent := indent
+			if b := &d.blocks[at.block]; !b.header {
+				keyIndent = b.indent
+			}
+			added[at.block] += keyIndent + at.prefix + tomlKeyText(k) + " = " + text + "\n"
+		}
+		return true
+	}
+	if !walk([]string{key}, top) {
+		return false
+	}
+
+	for _, i := range idx {
+		out[i] = res[i] + added[i]
+	}
+	extra[idx[len(idx)-1]] = strings.Join(newTables, "\n")
+	return true
+}
+
+// spliceArray renders the array of tables at path, written as the blocks
+// idx, holding the items of val. Items are matched with their originals
+// the way YAML lists are: unchanged ones keep their text, edited ones only
+// have the statements that changed rewritten and new ones are encoded. It
+// reports false when val isn't a list of tables.
+func (d *tomlDoc) spliceArray(path []string, idx []int, val interface{}) (string, bool) {
+	items, ok := tomlTables(val)
+	if !ok {
+		return "", false
+	}
+	origVal, _ := getPath(d.orig, path)
+	origItems, _ := tomlTables(origVal)
+
+	// The blocks of each original item, from its [[header]] on
+	var itemBlocks [][]int
+	indent := d.tableIndent()
+	for _, i := range idx {
+		b := &d.blocks[i]
+		if b.header && isTOMLArrayHeader(b) && pathKey(b.path) == pathKey(path) {
+			itemBlocks = append(itemBlocks, nil)
+		} else if !b.header && len(itemBlocks) == 1 {
+			indent = b.indent
+		}
+		if len(itemBlocks) > 0 {
+			itemBlocks[len(itemBlocks)-1] = append(itemBlocks[len(itemBlocks)-1], i)
+		}
+	}
+	if len(itemBlocks) != len(origItems) {
+		return "", false
+	}
+
+	values := make([]interface{}, len(items))
+	origValues := make([]interface{}, len(origItems))
+	for i, item := range items {
+		values[i] = item
+	}
+	for i, item := range origItems {
+		origValues[i] = item
+	}
+
+	var b strings.Builder
+	for i, j := range matchValues(values, origValues) {
+		lead := ""
+		if j >= 0 {
+			if text, ok := d.spliceItem(path, itemBlocks[j], origItems[j], items[i]); ok {
+				b.WriteString(text)
+				continue
+			}
+			lead = strings.Join(d.blocks[itemBlocks[j][0]].lead, "")
+		} else if i > 0 {
+			lead = "\n"
+		}
+		text, err := encodeTOMLTableArray(path, []map[string]interface{}{items[i]})
+		if err != nil {
+			return "", false
+		}
+		b.WriteString(lead + indentTOML(text, indent))
+	}
+	return b.String(), true
+}
+
+// spliceItem renders an item of the array of tables at path, written as
+// blocks when it was orig, rewriting only the statements that changed and
+// adding new keys after its last one. It reports false when the edit can't
+// be expressed that way, e.g. when a key is added to one of its tables.
+func (d *tomlDoc) spliceItem(path []string, blocks []int, orig, item map[string]interface{}) (string, bool) {
+	out := make([]string, len(blocks))
+	covered := make(map[string]bool)
+	last := 0 // Where keys are added: after the last statement of the item's own table
+	for n, i := range blocks {
+		b := &d.blocks[i]
+		rel := b.path[len(path):]
+		lead := strings.Join(b.lead, "")
+		if n == 0 {
+			out[n] = lead + strings.Join(b.text, "")
+			continue
+		}
+		if b.header && isTOMLArrayHeader(b) {
+			return "", false // Nested arrays of tables are encoded whole
+		}
+		covered[rel[0]] = true
+		if !b.header && len(b.table) == len(path) {
+			last = n
+		}
+
+		newVal, ok := getPath(item, rel)
+		if !ok {
+			if b.header {
+				return "", false
+			}
+			continue // removed
+		}
+		_, isMap := newVal.(map[string]interface{})
+		if b.header {
+			if !isMap {
+				return "", false
+			}
+			out[n] = lead + strings.Join(b.text, "")
+			continue
+		}
+		origVal, _ := getPath(orig, rel)
+		if sameValue(origVal, newVal) {
+			out[n] = lead + strings.Join(b.text, "")
+			continue
+		}
+		if _, wasMap := origVal.(map[string]interface{}); isMap && !wasMap {
+			return "", false
+		}
+		text, err := b.rewrite(newVal)
+		if err != nil {
+			return "", false
+		}
+		out[n] = lead + text
+	}
+
+	indent := d.blocks[blocks[last]].indent
+	if last == 0 {
+		indent = d.tableIndent()
+	}
+	for _, k := range sortedMapKeys(item) {
+		if covered[k] {
+			continue
+		}
+		if _, isMap := item[k].(map[string]interface{}); isMap || isTOMLTableArray(item[k]) {
+			return "", false
+		}
+		text, err := encodeTOMLInline(item[k])
+		if err != nil {
+			return "", false
+		}
+		out[last] += indent + tomlKeyText(k) + " = " + text + "\n"
+	}
+
+	// Keys added deeper down, into a table of the item, aren't handled
+	text := strings.Join(out, "")
+	var check map[string]interface{}
+	if _, err := toml.Decode(text, &check); err != nil {
+		return "", false
+	}
+	written, _ := getPath(check, path)
+	if list, ok := tomlTables(written); !ok || len(list) != 1 || !sameValue(list[0], item) {
+		return "", false
+	}
+	return text, true
+}
+
+// isTOMLArrayHeader reports whether a header block is an [[array]] item
+func isTOMLArrayHeader(b *tomlBlock) bool {
+	return strings.HasPrefix(strings.TrimSpace(b.text[0]), "[[")
+}
+
+// tomlTables returns the tables of an array of tables
+func tomlTables(val interface{}) ([]map[string]interface{}, bool) {
+	switch v := val.(type) {
+	case []map[string]interface{}:
+		return v, true
+	case []interface{}:
+		tables := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			tables[i] = m
+		}
+		return tables, true
+	}
+	return nil, false
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// tableIndent returns the indentation used for keys inside tables
+func (d *tomlDoc) tableIndent() string {
+	for i := range d.blocks {
+		if b := &d.blocks[i]; !b.header && len(b.table) > 0 {
+			return b.indent
+		}
+	}
+	return ""
+}
+
+// tomlComment returns the comment at the end of a one-line statement,
+// with the space before it
+func tomlComment(text []string) string {
+	if len(text) != 1 {
+		return ""
+	}
+	line := strings.TrimRight(text[0], "\r\n")
+	for i := strings.LastIndexByte(line, '#'); i > 0; i = strings.LastIndexByte(line[:i], '#') {
+		var v map[string]interface{}
+		if _, err := toml.Decode(line[:i], &v); err == nil {
+			stmt := strings.TrimRight(line[:i], " \t")
+			return line[len(stmt):]
+		}
+	}
+	return ""
+}
+
+// isTable reports whether path is a table with its own header
+func (d *tomlDoc) isTable(idx []int, path []string) bool {
+	for _, i := range idx {
+		if d.blocks[i].header && pathKey(d.blocks[i].path) == pathKey(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasBlockBelow reports whether a block lies below path
+func (d *tomlDoc) hasBlockBelow(idx []int, path []string) bool {
+	for _, i := range idx {
+		if len(d.blocks[i].path) > len(path) && hasPathPrefix(d.blocks[i].path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeTOMLKey renders a single key as TOML
+func encodeTOMLKey(key string, val interface{}) (string, error) {
+	buf := new(bytes.Buffer)
+	enc := toml.NewEncoder(buf)
+	enc.Indent = ""
+	if err := enc.Encode(map[string]interface{}{key: val}); err != nil {
+		return "", err
+	}
+	return strings.TrimLeft(buf.String(), "\n"), nil
+}
+
+var tomlHeader = regexp.MustCompile(`(?m)^(\[\[?)`)
+
+// encodeTOMLTable renders m as the table at path
+func encodeTOMLTable(path []string, m map[string]interface{}) (string, error) {
+	buf := new(bytes.Buffer)
+	enc := toml.NewEncoder(buf)
+	enc.Indent = ""
+	if err := enc.Encode(m); err != nil {
+		return "", err
+	}
+
+	// Headers of nested tables are relative to m, put them under path
+	parts := make([]string, len(path))
+	for i, p := range path {
+		parts[i] = tomlKeyText(p)
+	}
+	dotted := strings.Join(parts, ".")
+	body := tomlHeader.ReplaceAllString(strings.TrimLeft(buf.String(), "\n"), "${1}"+dotted+".")
+
+	return "[" + dotted + "]\n" + body, nil
+}
+
+// encodeTOMLTableArray renders list as the array of tables at path
+func encodeTOMLTableArray(path []string, list interface{}) (string, error) {
+	var items []interface{}
+	switch v := list.(type) {
+	case []map[string]interface{}:
+		for _, item := range v {
+			items = append(items, item)
+		}
+	case []interface{}:
+		items = v
+	default:
+		return "", fmt.Errorf("%s is not a list", strings.Join(path, "."))
+	}
+
+	parts := make([]string, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("%s: item %d is not a table", strings.Join(path, "."), i+1)
+		}
+		text, err := encodeTOMLTable(path, m)
+		if err != nil {
+			return "", err
+		}
+		header, body, _ := strings.Cut(text, "\n")
+		parts[i] = "[" + header + "]\n" + body
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// indentTOML indents the key/values of encoded tables
+func indentTOML(text, indent string) string {
+	if indent == "" {
+		return text
+	}
+	lines := splitLines(text)
+	for i, line := range lines {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "[") {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// encodeTOMLInline renders a value the way it appears after "key = ", with
+// maps as inline tables
+func encodeTOMLInline(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case nil:
+		return `""`, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			text, err := encodeTOMLInline(v[k])
+			if err != nil {
+				return "", err
+			}
+			parts[i] = tomlKeyText(k) + " = " + text
+		}
+		if len(parts) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	case []map[string]interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return encodeTOMLInline(items)
+	case []interface{}:
+		if !isTOMLTableArray(v) {
+			break
+		}
+		parts := make([]string, len(v))
+		for i, item := range v {
+			text, err := encodeTOMLInline(item)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = text
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	}
+
+	text, err := encodeTOMLKey("v", val)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(text, "v = "), "\n"), nil
+}
+
+// tomlKeyText quotes a key when it isn't a bare key
+func tomlKeyText(key string) string {
+	if key != "" && strings.IndexFunc(key, func(r rune) bool { return !isBareKeyChar(r) }) < 0 {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// isTOMLTableArray reports whether val is a list holding maps
+func isTOMLTableArray(val interface{}) bool {
+	switch v := val.(type) {
+	case []map[string]interface{}:
+		return true
+	case []interface{}:
+		for _, item := range v {
+			if _, ok := item.(map[string]interface{}); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeTable appends a table, keeping a blank line between tables
+func writeTable(b *strings.Builder, text string) {
+	if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n\n") && !strings.HasPrefix(text, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(text)
+}
+
+func isTOMLTable(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), "[")
+}
+
+func getPath(m map[string]interface{}, path []string) (interface{}, bool) {
+	var cur interface{} = m
+	for _, p := range path {
+		cm, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = cm[p]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func hasPathPrefix(path, prefix []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func pathKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
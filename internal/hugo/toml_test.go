@@ -0,0 +1,251 @@
+package hugo
+
+import (
+	"strings"
+	"testing"
+)
+
+const tomlConfig = `# Site settings
+baseURL = "https://example.org/"
+title = "My site"   # shown in the header
+params.author = "Ann"
+params.social.github = "ann"
+
+[markup.goldmark.renderer]
+  unsafe = true
+
+# Languages
+[languages]
+  [languages.en]
+    weight = 1
+    title = "English"
+  [languages.ar]
+    weight = 2
+    languageDirection = "rtl"
+
+[[menus.main]]
+  name = "Home"
+  url = "/"
+  weight = 1
+
+[[menus.main]]
+  name = "Posts"   # the blog
+  url = "/posts/"
+  weight = 2
+
+# The end
+`
+
+func TestTOMLRoundTrip(t *testing.T) {
+	d, err := ParseData(tomlConfig, "toml")
+	if err != nil {
+		t.Fatalf("ParseData: %v", err)
+	}
+	out, err := d.ToString()
+	if err != nil {
+		t.Fatalf("ToString: %v", err)
+	}
+	if out != tomlConfig {
+		t.Errorf("unchanged file was rewritten:\n%s", out)
+	}
+
+	md, err := ParseMD("+++\n" + tomlConfig + "+++\nBody\n")
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	page, err := md.ToString()
+	if err != nil {
+		t.Fatalf("ToString: %v", err)
+	}
+	if page != "+++\n"+tomlConfig+"+++\nBody\n" {
+		t.Errorf("unchanged page was rewritten:\n%s", page)
+	}
+}
+
+func TestTOMLEdit(t *testing.T) {
+	sub := func(m map[string]interface{}, path ...string) map[string]interface{} {
+		for _, k := range path {
+			m = m[k].(map[string]interface{})
+		}
+		return m
+	}
+
+	tests := []struct {
+		name string
+		edit func(data map[string]interface{})
+		want map[string]string // text replaced: old -> new, "" to remove
+	}{
+		{
+			name: "root key keeps comment",
+			edit: func(data map[string]interface{}) { data["title"] = "Your site" },
+			want: map[string]string{
+				`title = "My site"   # shown in the header` + "\n": `title = "Your site"   # shown in the header` + "\n",
+			},
+		},
+		{
+			name: "dotted key",
+			edit: func(data map[string]interface{}) { sub(data, "params", "social")["github"] = "bob" },
+			want: map[string]string{
+				`params.social.github = "ann"` + "\n": `params.social.github = "bob"` + "\n",
+			},
+		},
+		{
+			name: "added dotted key",
+			edit: func(data map[string]interface{}) { sub(data, "params")["mainSections"] = []interface{}{"posts"} },
+			want: map[string]string{
+				`params.author = "Ann"` + "\n": `params.author = "Ann"` + "\n" + `params.mainSections = ["posts"]` + "\n",
+			},
+		},
+		{
+			name: "subtable",
+			edit: func(data map[string]interface{}) { sub(data, "languages", "ar")["weight"] = int64(3) },
+			want: map[string]string{
+				"    weight = 2\n": "    weight = 3\n",
+			},
+		},
+		{
+			name: "key added to subtable",
+			edit: func(data map[string]interface{}) { sub(data, "languages", "en")["languageName"] = "English" },
+			want: map[string]string{
+				"    title = \"English\"\n": "    title = \"English\"\n    languageName = \"English\"\n",
+			},
+		},
+		{
+			name: "removed subtable key",
+			edit: func(data map[string]interface{}) { delete(sub(data, "languages", "ar"), "languageDirection") },
+			want: map[string]string{
+				"    languageDirection = \"rtl\"\n": "",
+			},
+		},
+		{
+			name: "untouched array of tables",
+			edit: func(data map[string]interface{}) { sub(data, "markup", "goldmark", "renderer")["unsafe"] = false },
+			want: map[string]string{
+				"  unsafe = true\n": "  unsafe = false\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseData(tomlConfig, "toml")
+			if err != nil {
+				t.Fatalf("ParseData: %v", err)
+			}
+			tt.edit(d.Data)
+			out, err := d.ToString()
+			if err != nil {
+				t.Fatalf("ToString: %v", err)
+			}
+
+			want := tomlConfig
+			for old, repl := range tt.want {
+				if !strings.Contains(want, old) {
+					t.Fatalf("test config has no %q", old)
+				}
+				want = strings.Replace(want, old, repl, 1)
+			}
+			if out != want {
+				t.Errorf("got:\n%s\nwant:\n%s", out, want)
+			}
+		})
+	}
+}
+
+const tomlResources = `title = "Trip"
+
+# Photos
+[[resources]]
+  src = "images/a.jpg"   # the cover
+  title = "Arrival"
+
+[[resources]]
+  src = "images/b.jpg"
+  title = "Beach"
+  [resources.params]
+    credits = "Ann"
+`
+
+func TestTOMLEditTableArray(t *testing.T) {
+	items := func(data map[string]interface{}, path ...string) []map[string]interface{} {
+		for _, k := range path[:len(path)-1] {
+			data = data[k].(map[string]interface{})
+		}
+		return data[path[len(path)-1]].([]map[string]interface{})
+	}
+
+	tests := []struct {
+		name string
+		text string
+		edit func(data map[string]interface{})
+		want map[string]string // text replaced: old -> new, "" to remove
+	}{
+		{
+			name: "item of a nested array",
+			text: tomlConfig,
+			edit: func(data map[string]interface{}) { items(data, "menus", "main")[1]["weight"] = int64(5) },
+			want: map[string]string{"  url = \"/posts/\"\n  weight = 2\n": "  url = \"/posts/\"\n  weight = 5\n"},
+		},
+		{
+			name: "item keeps its comment",
+			text: tomlResources,
+			edit: func(data map[string]interface{}) { items(data, "resources")[0]["title"] = "Arrivals" },
+			want: map[string]string{`  title = "Arrival"` + "\n": `  title = "Arrivals"` + "\n"},
+		},
+		{
+			name: "key added to an item",
+			text: tomlResources,
+			edit: func(data map[string]interface{}) { items(data, "resources")[0]["name"] = "cover" },
+			want: map[string]string{`  title = "Arrival"` + "\n": `  title = "Arrival"` + "\n" + `  name = "cover"` + "\n"},
+		},
+		{
+			name: "key of an item's table",
+			text: tomlResources,
+			edit: func(data map[string]interface{}) {
+				items(data, "resources")[1]["params"].(map[string]interface{})["credits"] = "Bob"
+			},
+			want: map[string]string{`    credits = "Ann"` + "\n": `    credits = "Bob"` + "\n"},
+		},
+		{
+			name: "removed item",
+			text: tomlResources,
+			edit: func(data map[string]interface{}) {
+				data["resources"] = items(data, "resources")[1:]
+			},
+			want: map[string]string{"# Photos\n[[resources]]\n  src = \"images/a.jpg\"   # the cover\n  title = \"Arrival\"\n\n": ""},
+		},
+		{
+			name: "added item",
+			text: tomlResources,
+			edit: func(data map[string]interface{}) {
+				data["resources"] = append(items(data, "resources"), map[string]interface{}{"src": "images/c.jpg"})
+			},
+			want: map[string]string{`    credits = "Ann"` + "\n": `    credits = "Ann"` + "\n\n[[resources]]\n  src = \"images/c.jpg\"\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseData(tt.text, "toml")
+			if err != nil {
+				t.Fatalf("ParseData: %v", err)
+			}
+			tt.edit(d.Data)
+			out, err := d.ToString()
+			if err != nil {
+				t.Fatalf("ToString: %v", err)
+			}
+
+			want := tt.text
+			for old, repl := range tt.want {
+				if !strings.Contains(want, old) {
+					t.Fatalf("test text has no %q", old)
+				}
+				want = strings.Replace(want, old, repl, 1)
+			}
+			if out != want {
+				t.Errorf("got:\n%s\nwant:\n%s", out, want)
+			}
+		})
+	}
+}
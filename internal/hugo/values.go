@@ -0,0 +1,188 @@
+package hugo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the date formats accepted when editing a date field
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// FormatValue renders a front matter value as editable text. Lists are
+// rendered as comma separated values.
+func FormatValue(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case time.Time:
+		return FormatDate(v)
+	case []string:
+		return strings.Join(v, ", ")
+	case []interface{}:
+		strs := make([]string, len(v))
+		for i, item := range v {
+			strs[i] = FormatValue(item)
+		}
+		return strings.Join(strs, ", ")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// ParseValue converts text back into a value of the same type as like, the
+// value the field held when it was read. It fails when text can't be
+// represented as that type.
+func ParseValue(text string, like interface{}) (interface{}, error) {
+	text = strings.TrimSpace(text)
+
+	switch v := like.(type) {
+	case nil, string:
+		return text, nil
+	case bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, errors.New("must be true or false")
+		}
+		return b, nil
+	case int:
+		i, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, errors.New("must be a whole number")
+		}
+		return i, nil
+	case int64:
+		i, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, errors.New("must be a whole number")
+		}
+		return i, nil
+	case uint64:
+		i, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return nil, errors.New("must be a positive whole number")
+		}
+		return i, nil
+	case float64:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, errors.New("must be a number")
+		}
+		return f, nil
+	case time.Time:
+		return ParseDate(text, v.Location())
+	case []string:
+		return splitList(text), nil
+	case []interface{}:
+		parts := splitList(text)
+		list := make([]interface{}, len(parts))
+		for i, p := range parts {
+			val, err := parseItem(p, i, v)
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", p, err)
+			}
+			list[i] = val
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("%T values can't be edited as text", like)
+	}
+}
+
+// parseItem converts the text of the item at i of a list back into a value.
+// An item written as one of the original items was is of its type, others
+// are of the type of the original item at i, else of the first type of the
+// list they can be, strings last since any text is one. Items of a list of
+// one type must all be of that type.
+func parseItem(text string, i int, likes []interface{}) (interface{}, error) {
+	for _, like := range likes {
+		if FormatValue(like) == text {
+			if val, err := ParseValue(text, like); err == nil {
+				return val, nil
+			}
+		}
+	}
+
+	var candidates, texts []interface{}
+	if i < len(likes) {
+		candidates = append(candidates, likes[i])
+	}
+	seen := make(map[string]bool)
+	for _, like := range likes {
+		typ := fmt.Sprintf("%T", like)
+		if seen[typ] {
+			continue
+		}
+		seen[typ] = true
+		switch like.(type) {
+		case nil, string:
+			texts = append(texts, like)
+		default:
+			candidates = append(candidates, like)
+		}
+	}
+	candidates = append(candidates, texts...)
+	if len(candidates) == 0 {
+		return text, nil
+	}
+
+	var first error
+	for _, like := range candidates {
+		val, err := ParseValue(text, like)
+		if err == nil {
+			return val, nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return nil, first
+}
+
+// FormatDate renders t as a date, with the time of day only when it is set
+func FormatDate(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// ParseDate parses a date in one of the formats Hugo accepts. Dates
+// without a zone are placed in loc.
+func ParseDate(text string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("must be a date like 2006-01-02 or 2006-01-02T15:04:05Z")
+}
+
+func splitList(text string) []string {
+	parts := strings.Split(text, ",")
+	list := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			list = append(list, p)
+		}
+	}
+	return list
+}
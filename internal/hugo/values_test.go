@@ -0,0 +1,48 @@
+package hugo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		like interface{}
+		want interface{}
+		err  bool
+	}{
+		{"string", " hello ", "old", "hello", false},
+		{"bool", "true", false, true, false},
+		{"bad bool", "yes please", false, nil, true},
+		{"int", "42", 1, 42, false},
+		{"int64", "42", int64(1), int64(42), false},
+		{"bad int", "4.2", 1, nil, true},
+		{"float", "4.5", 1.0, 4.5, false},
+		{"date", "2024-05-01", time.Time{}, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), false},
+		{"strings", "a, b,, c", []string{"x"}, []string{"a", "b", "c"}, false},
+		{"list of ints", "3, 1, 2", []interface{}{1, 2}, []interface{}{3, 1, 2}, false},
+		{"list of ints with text", "1, two", []interface{}{1, 2}, nil, true},
+		{"empty list", "a, b", []interface{}{}, []interface{}{"a", "b"}, false},
+		{"mixed list", "1, two", []interface{}{1, "two"}, []interface{}{1, "two"}, false},
+		{"mixed list edited", "3, four", []interface{}{1, "two"}, []interface{}{3, "four"}, false},
+		{"mixed list reordered", "two, 1", []interface{}{1, "two"}, []interface{}{"two", 1}, false},
+		{"mixed list grown", "1, two, 3, true", []interface{}{1, "two", true}, []interface{}{1, "two", 3, true}, false},
+		{"mixed list of numbers", "1, 2.5, 3", []interface{}{int64(1), 2.5}, []interface{}{int64(1), 2.5, int64(3)}, false},
+		{"map", "x", map[string]interface{}{}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseValue(tt.text, tt.like)
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want error %v", err, tt.err)
+			}
+			if !tt.err && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseValue(%q) = %#v, want %#v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,415 @@
+package hugo
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlDoc is YAML front matter split into the original text of each
+// top-level key.
+type yamlDoc struct {
+	segments []yamlSegment
+	tail     []string // blank and comment lines after the last key
+	indent   int
+	compact  bool // block lists are written at the column of their key
+}
+
+type yamlSegment struct {
+	key   string
+	lead  []string // blank and comment lines above the key
+	text  []string // the key and its value, nil if it must be re-encoded
+	knode *yaml.Node
+	vnode *yaml.Node
+	orig  interface{} // value as read, to detect edits
+}
+
+func parseYAML(fm string) (*yamlDoc, map[string]interface{}, error) {
+	meta := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(fm), &meta); err != nil {
+		return nil, nil, err
+	}
+
+	// A second copy, so in-place edits of nested values in meta are noticed
+	orig := make(map[string]interface{})
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(fm), &orig); err != nil {
+		return nil, nil, err
+	}
+	if err := yaml.Unmarshal([]byte(fm), &root); err != nil {
+		return nil, nil, err
+	}
+
+	lines := splitLines(fm)
+	doc := &yamlDoc{indent: detectIndent(lines)}
+	if len(root.Content) == 0 {
+		// Only comments
+		doc.tail = lines
+		return doc, meta, nil
+	}
+
+	mapping := root.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return doc, meta, nil
+	}
+	doc.compact, _ = compactSequences(mapping)
+	// A flow mapping ({title: x}) can't be split by line, every key is re-encoded
+	flow := mapping.Style&yaml.FlowStyle != 0
+
+	var lead []string
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		k, v := mapping.Content[i], mapping.Content[i+1]
+		seg := yamlSegment{key: k.Value, knode: k, vnode: v, orig: orig[k.Value]}
+
+		if !flow {
+			start := k.Line - 1
+			end := len(lines)
+			if i+2 < len(mapping.Content) {
+				end = mapping.Content[i+2].Line - 1
+			}
+			if i == 0 {
+				lead = lines[:start]
+			}
+			seg.lead = lead
+			seg.text, lead = splitTrivia(lines[start:end])
+		}
+
+		doc.segments = append(doc.segments, seg)
+	}
+	doc.tail = lead
+
+	return doc, meta, nil
+}
+
+func (d *yamlDoc) keys() []string {
+	keys := make([]string, len(d.segments))
+	for i, seg := range d.segments {
+		keys[i] = seg.key
+	}
+	return keys
+}
+
+func (d *yamlDoc) encode(meta map[string]interface{}) (string, error) {
+	var b strings.Builder
+	written := make(map[string]bool, len(meta))
+
+	for i := range d.segments {
+		seg := &d.segments[i]
+		val, ok := meta[seg.key]
+		if !ok || written[seg.key] {
+			// Removed, drop it together with the comments above it
+			continue
+		}
+		written[seg.key] = true
+
+		writeLines(&b, seg.lead)
+		if seg.text != nil && sameValue(seg.orig, val) {
+			writeLines(&b, seg.text)
+			continue
+		}
+		out, err := d.encodeKey(seg.key, val, seg)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(out)
+	}
+
+	for _, k := range orderedKeys(nil, meta) {
+		if written[k] {
+			continue
+		}
+		out, err := d.encodeKey(k, meta[k], nil)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(out)
+	}
+
+	writeLines(&b, d.tail)
+	return b.String(), nil
+}
+
+// encodeKey renders a single "key: value" entry. When seg is given the
+// value keeps the quoting, flow style and comment of the original.
+func (d *yamlDoc) encodeKey(key string, val interface{}, seg *yamlSegment) (string, error) {
+	vnode := new(yaml.Node)
+	if err := vnode.Encode(val); err != nil {
+		return "", err
+	}
+	knode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	if seg != nil {
+		knode.Style = seg.knode.Style
+		knode.LineComment = seg.knode.LineComment
+		keepStyle(vnode, seg.vnode)
+	}
+
+	buf := new(bytes.Buffer)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(d.indent)
+	if err := enc.Encode(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{knode, vnode}}); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	if d.compact {
+		return outdentSequences(buf.String()), nil
+	}
+	return buf.String(), nil
+}
+
+// compactSequences reports whether the first block list found under a key
+// is written at the column of the key, as in
+//
+//	tags:
+//	- go
+//
+// rather than indented, which is how the encoder writes them
+func compactSequences(n *yaml.Node) (compact, found bool) {
+	for i, child := range n.Content {
+		if n.Kind == yaml.MappingNode && i%2 == 1 && child.Kind == yaml.SequenceNode &&
+			child.Style&yaml.FlowStyle == 0 && len(child.Content) > 0 {
+			// Items start after "- "
+			return child.Content[0].Column-2 <= n.Content[i-1].Column, true
+		}
+		if compact, found := compactSequences(child); found {
+			return compact, true
+		}
+	}
+	return false, false
+}
+
+var (
+	yamlEmptyKey    = regexp.MustCompile(`^((?:- )*)[^#\s].*:(?:\s+#.*)?$`)
+	yamlBlockScalar = regexp.MustCompile(`(?:^|\s)[|>][-+0-9]*(?:\s+#.*)?$`)
+)
+
+// outdentSequences moves the block lists the encoder indents under their
+// key back to the column of the key
+func outdentSequences(text string) string {
+	type list struct{ key, shift int }
+	var open []list
+	block := -1 // Column of the line starting a block scalar, whose content is left as it is
+	lines := splitLines(text)
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if strings.TrimSpace(trimmed) == "" {
+			continue
+		}
+		col := len(line) - len(trimmed)
+		for len(open) > 0 && col <= open[len(open)-1].key {
+			open = open[:len(open)-1]
+		}
+		shift := 0
+		for _, l := range open {
+			shift += l.shift
+		}
+		lines[i] = line[shift:]
+
+		if block >= 0 && col > block {
+			continue
+		}
+		block = -1
+		content := strings.TrimRight(trimmed, "\r\n")
+		if yamlBlockScalar.MatchString(content) {
+			block = col
+			continue
+		}
+		m := yamlEmptyKey.FindStringSubmatch(content)
+		if m == nil {
+			continue
+		}
+		// The first item, after any comment above it
+		for _, next := range lines[i+1:] {
+			trimmed := strings.TrimLeft(next, " ")
+			if strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			key, item := col+len(m[1]), len(next)-len(trimmed)
+			if strings.HasPrefix(trimmed, "-") && item > key {
+				open = append(open, list{key: key, shift: item - key})
+			}
+			break
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// keepStyle carries the presentation of orig over to its replacement n
+func keepStyle(n, orig *yaml.Node) {
+	if orig == nil || n.Kind != orig.Kind {
+		return
+	}
+	n.HeadComment = orig.HeadComment
+	n.LineComment = orig.LineComment
+
+	switch n.Kind {
+	case yaml.ScalarNode:
+		multiline := strings.Contains(n.Value, "\n")
+		quoted := orig.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0
+		block := orig.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0
+		if n.Tag == orig.Tag && ((quoted && !multiline) || (block && multiline)) {
+			n.Style = orig.Style
+		}
+		// Dates written without a time of day stay that way
+		if n.Tag == "!!timestamp" && orig.Tag == "!!timestamp" && len(orig.Value) == len("2006-01-02") {
+			if t, err := time.Parse(time.RFC3339Nano, n.Value); err == nil && FormatDate(t) == n.Value[:len(orig.Value)] {
+				n.Value = n.Value[:len(orig.Value)]
+			}
+		}
+	case yaml.SequenceNode:
+		n.Style = orig.Style & yaml.FlowStyle
+		for i, item := range matchItems(n.Content, orig.Content) {
+			if item != nil {
+				keepStyle(n.Content[i], item)
+			}
+		}
+	case yaml.MappingNode:
+		n.Style = orig.Style & yaml.FlowStyle
+
+		pos := make(map[string]int, len(orig.Content)/2)
+		for i := 0; i+1 < len(orig.Content); i += 2 {
+			pos[orig.Content[i].Value] = i
+		}
+		rank := func(k *yaml.Node) int {
+			if i, ok := pos[k.Value]; ok {
+				return i
+			}
+			return len(orig.Content)
+		}
+
+		// Keep the original key order, new keys go last
+		pairs := make([][2]*yaml.Node, 0, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			pairs = append(pairs, [2]*yaml.Node{n.Content[i], n.Content[i+1]})
+		}
+		sort.SliceStable(pairs, func(i, j int) bool { return rank(pairs[i][0]) < rank(pairs[j][0]) })
+
+		n.Content = n.Content[:0]
+		for _, p := range pairs {
+			if i, ok := pos[p[0].Value]; ok {
+				k := orig.Content[i]
+				p[0].Style = k.Style
+				p[0].HeadComment = k.HeadComment
+				p[0].LineComment = k.LineComment
+				keepStyle(p[1], orig.Content[i+1])
+			}
+			n.Content = append(n.Content, p[0], p[1])
+		}
+	}
+}
+
+// matchItems finds the original of each item of a list, so that the
+// comments of an item removed or moved don't end up on another. Items
+// without an original get nil.
+func matchItems(items, origs []*yaml.Node) []*yaml.Node {
+	match := make([]*yaml.Node, len(items))
+	for i, j := range matchValues(decodeNodes(items), decodeNodes(origs)) {
+		if j >= 0 {
+			match[i] = origs[j]
+		}
+	}
+	return match
+}
+
+// matchValues returns the index of the original of each item of a list:
+// the item of the same value, else for an edited record the one sharing
+// the most fields with it. Items without an original get -1.
+func matchValues(values, origValues []interface{}) []int {
+	byText := make(map[string][]int) // encoded value -> unused originals
+	for j, v := range origValues {
+		text := encodeValue(v)
+		byText[text] = append(byText[text], j)
+	}
+	match := make([]int, len(values))
+	used := make([]bool, len(origValues))
+	for i, v := range values {
+		match[i] = -1
+		text := encodeValue(v)
+		if js := byText[text]; len(js) > 0 {
+			match[i] = js[0]
+			used[js[0]] = true
+			byText[text] = js[1:]
+		}
+	}
+
+	for i, v := range values {
+		if match[i] >= 0 {
+			continue
+		}
+		best, bestShared := -1, 0
+		for j, o := range origValues {
+			if shared := sharedFields(v, o); !used[j] && shared > bestShared {
+				best, bestShared = j, shared
+			}
+		}
+		if best >= 0 {
+			match[i] = best
+			used[best] = true
+		}
+	}
+	return match
+}
+
+func decodeNodes(nodes []*yaml.Node) []interface{} {
+	values := make([]interface{}, len(nodes))
+	for i, n := range nodes {
+		n.Decode(&values[i])
+	}
+	return values
+}
+
+func encodeValue(v interface{}) string {
+	text, err := yaml.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(text)
+}
+
+// sharedFields counts the keys two records have the same value for
+func sharedFields(a, b interface{}) int {
+	ma, ok := a.(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	mb, ok := b.(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	shared := 0
+	for k, v := range ma {
+		if w, ok := mb[k]; ok && sameValue(v, w) {
+			shared++
+		}
+	}
+	return shared
+}
+
+// detectIndent guesses the indentation width used by the file
+func detectIndent(lines []string) int {
+	indent := 0
+	for _, line := range lines {
+		if isTrivia(line) {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if n > 0 && (indent == 0 || n < indent) {
+			indent = n
+		}
+	}
+	if indent < 2 || indent > 8 {
+		return 2
+	}
+	return indent
+}
+
+func writeLines(b *strings.Builder, lines []string) {
+	for _, line := range lines {
+		b.WriteString(line)
+	}
+}
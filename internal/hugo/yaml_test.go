@@ -0,0 +1,118 @@
+package hugo
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const yamlPage = `---
+# The page
+title: "Hello, world"   # shown in the header
+date: 2024-05-01
+tags: [go, hugo]
+
+params:
+  author: Ann
+  # Nested comment
+  image: cover.png
+menu:
+  main:
+    weight: 10
+description: |
+  Two lines
+  of text
+# Trailing comment
+---
+Body text
+`
+
+func TestYAMLRoundTrip(t *testing.T) {
+	md, err := ParseMD(yamlPage)
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	out, err := md.ToString()
+	if err != nil {
+		t.Fatalf("ToString: %v", err)
+	}
+	if out != yamlPage {
+		t.Errorf("unchanged file was rewritten:\n%s", out)
+	}
+}
+
+func TestYAMLEdit(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(meta map[string]interface{})
+		want map[string]string // lines replaced: old -> new, "" to remove
+		add  string            // text expected to be added
+	}{
+		{
+			name: "string keeps quotes and comment",
+			edit: func(meta map[string]interface{}) { meta["title"] = "Goodbye" },
+			want: map[string]string{
+				`title: "Hello, world"   # shown in the header` + "\n": `title: "Goodbye" # shown in the header` + "\n",
+			},
+		},
+		{
+			name: "date keeps its layout",
+			edit: func(meta map[string]interface{}) {
+				meta["date"] = time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+			},
+			want: map[string]string{"date: 2024-05-01\n": "date: 2024-06-02\n"},
+		},
+		{
+			name: "flow list stays flow",
+			edit: func(meta map[string]interface{}) {
+				meta["tags"] = []interface{}{"go", "hugo", "cms"}
+			},
+			want: map[string]string{"tags: [go, hugo]\n": "tags: [go, hugo, cms]\n"},
+		},
+		{
+			name: "nested value keeps comments",
+			edit: func(meta map[string]interface{}) {
+				meta["params"].(map[string]interface{})["author"] = "Bob"
+			},
+			want: map[string]string{"  author: Ann\n": "  author: Bob\n"},
+		},
+		{
+			name: "removed key",
+			edit: func(meta map[string]interface{}) { delete(meta, "menu") },
+			want: map[string]string{"menu:\n  main:\n    weight: 10\n": ""},
+		},
+		{
+			name: "added key",
+			edit: func(meta map[string]interface{}) { meta["draft"] = true },
+			add:  "draft: true\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md, err := ParseMD(yamlPage)
+			if err != nil {
+				t.Fatalf("ParseMD: %v", err)
+			}
+			tt.edit(md.MetaData)
+			out, err := md.ToString()
+			if err != nil {
+				t.Fatalf("ToString: %v", err)
+			}
+
+			want := yamlPage
+			for old, repl := range tt.want {
+				if !strings.Contains(want, old) {
+					t.Fatalf("test page has no %q", old)
+				}
+				want = strings.Replace(want, old, repl, 1)
+			}
+			if tt.add != "" {
+				want = strings.Replace(want, "# Trailing comment\n", tt.add+"# Trailing comment\n", 1)
+			}
+			if out != want {
+				t.Errorf("got:\n%s\nwant:\n%s", out, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AliasCollision is an alias of a page that is also the URL of another
+// page, or an alias of another page too
+type AliasCollision struct {
+	Alias string
+	Page  *Page // The page with the alias
+	Other *Page // The page living at the alias, or with the same alias
+	Real  bool  // Other lives at the alias rather than having it as alias
+}
+
+// Aliases returns the aliases of p as URL paths, e.g. /old/path/. Aliases
+// without a leading slash are relative to the language of the page.
+func (c *SiteConfig) Aliases(p *Page) []string {
+	var out []string
+	for _, alias := range getStrings(p.Meta, "aliases") {
+		out = append(out, c.aliasPath(p, alias))
+	}
+	return out
+}
+
+func (c *SiteConfig) aliasPath(p *Page, alias string) string {
+	if !strings.HasPrefix(alias, "/") {
+		prefix := ""
+		if p.Lang != c.DefaultContentLanguage || c.DefaultContentLanguageInSubdir {
+			prefix = "/" + p.Lang
+		}
+		alias = prefix + "/" + alias
+	}
+	if path.Ext(alias) == "" && !strings.HasSuffix(alias, "/") {
+		alias += "/"
+	}
+	return alias
+}
+
+// AddAlias adds alias to the aliases of the page at path, unless it has it
+func AddAlias(path, alias string) error {
+	return UpdatePage(path, func(meta map[string]interface{}) error {
+		AppendAlias(meta, alias)
+		return nil
+	})
+}
+
+// AppendAlias adds alias to the aliases of front matter, turning a single
+// alias into a list. It returns the key of the aliases, or "" when they
+// already hold it.
+func AppendAlias(meta map[string]interface{}, alias string) string {
+	aliases, key := lookup(meta, "aliases")
+	if key == "" {
+		key = "aliases"
+	}
+	list := asList(aliases)
+	if s, ok := aliases.(string); ok && s != "" {
+		list = []interface{}{s}
+	}
+	for _, a := range list {
+		if fmt.Sprint(a) == alias {
+			return ""
+		}
+	}
+	meta[key] = append(list, alias)
+	return key
+}
+
+// MovePages renames content files and directories, from old to new
+// absolute paths, and adds the URLs the pages had to their aliases so
+// links to them keep working. Drafts, which were never published, don't
+// get aliases. When a rename fails, those done are undone, so translations
+// aren't left apart.
+func (c *SiteConfig) MovePages(pages []*Page, moves map[string]string) error {
+	sep := string(filepath.Separator)
+	now := time.Now()
+	oldURLs := make(map[string]string) // new path -> URL before the move
+	froms := make([]string, 0, len(moves))
+	for from, to := range moves {
+		if _, err := os.Stat(to); err == nil {
+			return fmt.Errorf("%s already exists", filepath.Base(to))
+		}
+		for _, p := range pages {
+			if p.Path != from && !strings.HasPrefix(p.Path, from+sep) {
+				continue
+			}
+			if p.Status(now) != StatusDraft {
+				oldURLs[to+strings.TrimPrefix(p.Path, from)] = c.RelPermalink(p)
+			}
+		}
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+
+	var done []string
+	created := make(map[string]string) // from -> first directory its move created
+	for _, from := range froms {
+		dir, err := movePath(from, moves[from])
+		if err != nil {
+			for i := len(done) - 1; i >= 0; i-- {
+				back := done[i]
+				if undoErr := os.Rename(moves[back], back); undoErr != nil {
+					return fmt.Errorf("%w, and %s couldn't be moved back: %v", err, filepath.Base(back), undoErr)
+				}
+				removeEmptyDirs(filepath.Dir(moves[back]), created[back])
+			}
+			return err
+		}
+		done = append(done, from)
+		created[from] = dir
+	}
+
+	moved, err := c.Pages()
+	if err != nil {
+		return err
+	}
+	var aliasErr error
+	for _, p := range moved {
+		old, ok := oldURLs[p.Path]
+		if !ok || old == c.RelPermalink(p) {
+			continue
+		}
+		if err := AddAlias(p.Path, old); err != nil && aliasErr == nil {
+			aliasErr = err
+		}
+	}
+	return aliasErr
+}
+
+// movePath renames from to to, creating the directories to needs. It
+// returns the first directory it created, if any.
+func movePath(from, to string) (string, error) {
+	dir := filepath.Dir(to)
+	created := ""
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil || filepath.Dir(d) == d {
+			break
+		}
+		created = d
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(from, to); err != nil {
+		removeEmptyDirs(dir, created)
+		return "", err
+	}
+	return created, nil
+}
+
+// removeEmptyDirs removes dir and its parents up to top while they are
+// empty. Nothing is removed when top is empty.
+func removeEmptyDirs(dir, top string) {
+	if top == "" {
+		return
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		if os.Remove(d) != nil || d == top {
+			return
+		}
+	}
+}
+
+// TranslationMoves returns the moves renaming the content file at from
+// along with its translations named after it, e.g. post.md and post.ar.md,
+// to the file at to and its translations
+func (c *SiteConfig) TranslationMoves(from, to string) map[string]string {
+	moves := make(map[string]string)
+	dir := filepath.Dir(from)
+	_, name := c.SplitLang(filepath.Base(from))
+	ext := filepath.Ext(to)
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		lang, base := c.SplitLang(entry.Name())
+		if entry.IsDir() || base != name {
+			continue
+		}
+		target := to
+		if lang != "" {
+			target = strings.TrimSuffix(to, ext) + "." + lang + ext
+		}
+		moves[filepath.Join(dir, entry.Name())] = target
+	}
+	return moves
+}
+
+// AliasCollisions finds aliases that are the URL of another page, which
+// Hugo then overwrites with a redirect or the other way round, and
+// aliases shared by several pages
+func (c *SiteConfig) AliasCollisions(pages []*Page) []AliasCollision {
+	urls := make(map[string]*Page)
+	for _, p := range pages {
+		urls[c.RelPermalink(p)] = p
+	}
+
+	var out []AliasCollision
+	owners := make(map[string]*Page)
+	for _, p := range pages {
+		for _, alias := range c.Aliases(p) {
+			if other, ok := urls[alias]; ok && other != p {
+				out = append(out, AliasCollision{Alias: alias, Page: p, Other: other, Real: true})
+			}
+			if other, ok := owners[alias]; ok && other != p {
+				out = append(out, AliasCollision{Alias: alias, Page: p, Other: other})
+			}
+			owners[alias] = p
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Alias < out[j].Alias })
+	return out
+}
@@ -0,0 +1,92 @@
+package site
+
+import (
+	"time"
+)
+
+// The publication status of a page
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+	StatusExpired   = "expired"
+)
+
+// The front matter keys Hugo reads each date from, in order
+var (
+	dateKeys        = []string{"date", "publishDate", "pubdate", "published", "lastmod", "modified"}
+	publishDateKeys = []string{"publishDate", "pubdate", "published", "date"}
+	expiryDateKeys  = []string{"expiryDate", "unpublishdate"}
+	lastmodKeys     = []string{"lastmod", "modified", "date", "publishDate", "pubdate", "published"}
+)
+
+// Date returns the date of p, with Hugo's fallbacks
+func (p *Page) Date() (time.Time, bool) { return p.firstTime(dateKeys) }
+
+// PublishDate returns when p gets published, which is its date unless
+// publishDate is set
+func (p *Page) PublishDate() (time.Time, bool) { return p.firstTime(publishDateKeys) }
+
+// ExpiryDate returns when p stops being published
+func (p *Page) ExpiryDate() (time.Time, bool) { return p.firstTime(expiryDateKeys) }
+
+// Lastmod returns when p was last changed, by its front matter
+func (p *Page) Lastmod() (time.Time, bool) { return p.firstTime(lastmodKeys) }
+
+func (p *Page) firstTime(keys []string) (time.Time, bool) {
+	for _, k := range keys {
+		if t, ok := p.Time(k); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Status returns whether Hugo builds p at now: drafts and pages published
+// in the future or expired are left out by default
+func (p *Page) Status(now time.Time) string {
+	if getBool(p.Meta, "draft") {
+		return StatusDraft
+	}
+	if t, ok := p.ExpiryDate(); ok && !t.After(now) {
+		return StatusExpired
+	}
+	if t, ok := p.PublishDate(); ok && t.After(now) {
+		return StatusScheduled
+	}
+	return StatusPublished
+}
+
+// PublishDateKey returns the front matter key that sets when p gets
+// published: publishDate or one of its aliases, else date
+func (p *Page) PublishDateKey() string {
+	return p.keyOf(publishDateKeys, "date")
+}
+
+// ExpiryDateKey returns the front matter key that sets when p expires
+func (p *Page) ExpiryDateKey() string {
+	return p.keyOf(expiryDateKeys, "expiryDate")
+}
+
+// keyOf returns the first of keys that p sets, as written, or def
+func (p *Page) keyOf(keys []string, def string) string {
+	for _, k := range keys {
+		if _, key := lookup(p.Meta, k); key != "" {
+			return key
+		}
+	}
+	return def
+}
+
+// Reschedule sets a date of the page at path, such as its publishDate,
+// keeping the rest of the file as it is. A date replacing another keeps
+// its zone, so TOML local dates stay dates.
+func Reschedule(path, key string, t time.Time) error {
+	return UpdatePage(path, func(meta map[string]interface{}) error {
+		if old, ok := meta[key].(time.Time); ok {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), old.Location())
+		}
+		meta[key] = t
+		return nil
+	})
+}
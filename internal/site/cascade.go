@@ -0,0 +1,221 @@
+package site
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+)
+
+// Where an effective value comes from
+const (
+	OriginPage    = "page"
+	OriginCascade = "cascade"
+	OriginSite    = "site params"
+)
+
+// EffectiveValue is a parameter of a page as Hugo sees it, with where it
+// was set
+type EffectiveValue struct {
+	Key    string
+	Value  interface{}
+	Origin string // OriginPage, OriginCascade or OriginSite
+	Source string // The file that sets it, e.g. posts/_index.md or hugo.toml
+}
+
+// Kind returns the kind of page Hugo gives p: home, section or page
+func (p *Page) Kind() string {
+	if _, branch := cms.IsBundleIndex(p.Path); branch {
+		if path.Dir(p.keyPath()) == "." {
+			return "home"
+		}
+		return "section"
+	}
+	return "page"
+}
+
+// Effective returns the parameters of p: its front matter, then what the
+// cascade of its sections and the site pushes down to it, then the site
+// params that .Param falls back to. Values set closer to the page win.
+func (c *SiteConfig) Effective(pages []*Page, p *Page) []EffectiveValue {
+	var out []EffectiveValue
+	seen := make(map[string]bool)
+	add := func(key string, val interface{}, origin, source string) {
+		if seen[strings.ToLower(key)] {
+			return
+		}
+		seen[strings.ToLower(key)] = true
+		out = append(out, EffectiveValue{Key: key, Value: val, Origin: origin, Source: source})
+	}
+
+	for _, k := range sortedKeys(p.Meta) {
+		add(k, p.Meta[k], OriginPage, p.Rel)
+	}
+
+	// The cascade of the closest section first
+	for _, section := range c.Ancestors(pages, p) {
+		for _, values := range c.cascadeFor(section.Meta, p) {
+			for _, k := range sortedKeys(values) {
+				add(k, values[k], OriginCascade, section.Rel)
+			}
+		}
+	}
+	if _, key := lookup(c.Raw, "cascade"); key != "" {
+		for _, values := range c.cascadeFor(c.Raw, p) {
+			for _, k := range sortedKeys(values) {
+				add(k, values[k], OriginCascade, relTo(c.Dir, c.SourceOf("cascade")))
+			}
+		}
+	}
+
+	// Language params override the params of the site
+	if lang, ok := c.Language(p.Lang); ok && c.Multilingual() {
+		for _, k := range sortedKeys(lang.Params) {
+			add(k, lang.Params[k], OriginSite, relTo(c.Dir, c.SourceOf("languages."+lang.Code+".params."+k)))
+		}
+	}
+	for _, k := range sortedKeys(c.Params) {
+		add(k, c.Params[k], OriginSite, relTo(c.Dir, c.SourceOf("params."+k)))
+	}
+	return out
+}
+
+// Ancestors returns the section pages above p in the same language,
+// closest first, ending with the home page
+func (c *SiteConfig) Ancestors(pages []*Page, p *Page) []*Page {
+	dir := path.Dir(p.keyPath())
+	if isIndex, _ := cms.IsBundleIndex(p.Path); isIndex {
+		if dir == "." {
+			return nil // The home page
+		}
+		dir = path.Dir(dir)
+	}
+
+	bySection := make(map[string]*Page)
+	for _, q := range pages {
+		if q.Lang == p.Lang && q.Kind() != "page" {
+			bySection[path.Dir(q.keyPath())] = q
+		}
+	}
+
+	var out []*Page
+	for {
+		if section, ok := bySection[dir]; ok {
+			out = append(out, section)
+		}
+		if dir == "." {
+			return out
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// Descendants returns the pages below a section page in its language
+func (c *SiteConfig) Descendants(pages []*Page, section *Page) []*Page {
+	var out []*Page
+	for _, q := range pages {
+		if q == section || q.Lang != section.Lang {
+			continue
+		}
+		for _, a := range c.Ancestors(pages, q) {
+			if a == section {
+				out = append(out, q)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// cascadeFor returns the values of the cascade in meta that target p. The
+// cascade is a map, or a list of maps each with its own target.
+func (c *SiteConfig) cascadeFor(meta map[string]interface{}, p *Page) []map[string]interface{} {
+	v, _ := lookup(meta, "cascade")
+	var entries []map[string]interface{}
+	if m, ok := asMap(v); ok {
+		entries = append(entries, m)
+	}
+	for _, item := range asList(v) {
+		if m, ok := asMap(item); ok {
+			entries = append(entries, m)
+		}
+	}
+
+	var out []map[string]interface{}
+	for _, entry := range entries {
+		values := make(map[string]interface{}, len(entry))
+		var target map[string]interface{}
+		for k, val := range entry {
+			if strings.EqualFold(k, "_target") || strings.EqualFold(k, "target") {
+				target, _ = asMap(val)
+				continue
+			}
+			values[k] = val
+		}
+		if c.matchesTarget(target, p) {
+			out = append(out, values)
+		}
+	}
+	return out
+}
+
+// matchesTarget reports whether p is selected by a cascade target, which
+// can filter on path (a glob), kind, lang and environment
+func (c *SiteConfig) matchesTarget(target map[string]interface{}, p *Page) bool {
+	if pattern := getString(target, "path"); pattern != "" && !matchGlob(pattern, p.Ref()) {
+		return false
+	}
+	if kind := getString(target, "kind"); kind != "" && !matchGlob(kind, p.Kind()) {
+		return false
+	}
+	if lang := getString(target, "lang"); lang != "" && !matchGlob(lang, p.Lang) {
+		return false
+	}
+	if env := getString(target, "environment"); env != "" && !matchGlob(env, c.Environment) {
+		return false
+	}
+	return true
+}
+
+// matchGlob matches a Hugo glob, where ** also matches across slashes
+func matchGlob(pattern, name string) bool {
+	pattern = strings.ToLower(pattern)
+	name = strings.ToLower(name)
+	if ok, _ := path.Match(pattern, name); ok {
+		return true
+	}
+	if before, after, ok := strings.Cut(pattern, "**"); ok {
+		if !strings.HasPrefix(name, before) {
+			return false
+		}
+		rest := name[len(before):]
+		after = strings.TrimPrefix(after, "/")
+		if after == "" {
+			return true
+		}
+		for i := 0; i <= len(rest); i++ {
+			if matchGlob(after, rest[i:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// relTo returns file relative to dir for display
+func relTo(dir, file string) string {
+	if rel, ok := strings.CutPrefix(file, dir); ok && rel != file {
+		return strings.TrimLeft(rel, `/\`)
+	}
+	return file
+}
@@ -0,0 +1,434 @@
+// Package site reads the configuration of a Hugo site the way Hugo
+// resolves it.
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configNames are the root config files, in the order Hugo looks for them
+var configNames = []string{
+	"hugo.toml", "hugo.yaml", "hugo.yml", "hugo.json",
+	"config.toml", "config.yaml", "config.yml", "config.json",
+}
+
+// SiteConfig is the merged configuration of a Hugo site
+type SiteConfig struct {
+	Dir         string // The site root
+	Environment string
+
+	Title                          string
+	BaseURL                        string
+	LanguageCode                   string
+	DefaultContentLanguage         string
+	DefaultContentLanguageInSubdir bool
+	ContentDir                     string
+	Theme                          []string
+	UglyURLs                       bool
+	SummaryLength                  int
+	TransliterateSlugs             bool // params.bayan.slugs = "transliterate", for new slugs
+
+	Languages  []Language        // Sorted by weight, never empty
+	Taxonomies map[string]string // singular -> plural, e.g. tag -> tags
+	Permalinks map[string]string // section -> pattern, for pages
+	Params     map[string]interface{}
+	Menus      map[string][]MenuEntry
+
+	// Raw is the merged configuration as read
+	Raw map[string]interface{}
+	// Files are the files that were merged, in order
+	Files []string
+
+	sources map[string]string // lower case dotted key -> file that set it
+	mounts  map[string]string // file -> dotted key its content is placed under
+	pages   *pageCache        // content files as last read
+}
+
+// Language is an entry of languages, or the only language of a
+// monolingual site
+type Language struct {
+	Code              string
+	Name              string // languageName
+	Title             string
+	Weight            int
+	ContentDir        string
+	LanguageCode      string
+	LanguageDirection string
+	Params            map[string]interface{}
+	Menus             map[string][]MenuEntry
+}
+
+// MenuEntry is an entry of a menu defined in the site configuration
+type MenuEntry struct {
+	Identifier string
+	Name       string
+	URL        string
+	PageRef    string
+	Parent     string
+	Pre        string
+	Post       string
+	Weight     int
+	Params     map[string]interface{}
+}
+
+// Load reads the configuration of the site in dir for environment
+// ("production" if empty): the root config file, then config/_default and
+// config/<environment>, each overriding the one before.
+func Load(dir, environment string) (*SiteConfig, error) {
+	if environment == "" {
+		environment = "production"
+	}
+	c := New(dir)
+	c.Environment = environment
+
+	for _, name := range configNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			if err := c.mergeFile(path, ""); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	for _, sub := range []string{"_default", environment} {
+		if err := c.mergeDir(filepath.Join(dir, "config", sub)); err != nil {
+			return nil, err
+		}
+	}
+
+	c.resolve()
+	return c, nil
+}
+
+// Snapshot returns a copy of the configuration as it is now, for work done
+// in the background while Save may reload it. The copy reads the content
+// through the same cache.
+func (c *SiteConfig) Snapshot() *SiteConfig {
+	snapshot := *c
+	return &snapshot
+}
+
+// New returns the configuration Hugo uses for a site without any config
+func New(dir string) *SiteConfig {
+	c := &SiteConfig{
+		Dir:         dir,
+		Environment: "production",
+		Raw:         make(map[string]interface{}),
+		sources:     make(map[string]string),
+		mounts:      make(map[string]string),
+		pages:       &pageCache{files: make(map[string]cachedPage)},
+	}
+	c.resolve()
+	return c
+}
+
+// mergeDir merges a config directory. hugo.toml/config.toml hold root
+// keys, other files are named after the key they hold, e.g. params.toml,
+// or menus.en.toml for the menus of a language. hugo.en.toml holds the
+// settings of a language.
+func (c *SiteConfig) mergeDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	// Root files first, so the key files can override them
+	sort.SliceStable(entries, func(i, j int) bool {
+		return isRootConfig(entries[i].Name()) && !isRootConfig(entries[j].Name())
+	})
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || configFormat(name) == "" {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if isRootConfig(name) {
+			if err := c.mergeFile(path, ""); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := strings.TrimSuffix(name, filepath.Ext(name))
+		if base, lang, ok := strings.Cut(key, "."); ok {
+			// hugo.en.toml holds the root keys of a language
+			key = "languages." + lang
+			if !isRootConfig(base + filepath.Ext(name)) {
+				key += "." + base
+			}
+		}
+		if err := c.mergeFile(path, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeFile merges a config file, placing its content under the dotted key
+// when given.
+func (c *SiteConfig) mergeFile(path, key string) error {
+	data, err := ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+
+	if key != "" {
+		parts := strings.Split(key, ".")
+		for i := len(parts) - 1; i >= 0; i-- {
+			data = map[string]interface{}{parts[i]: data}
+		}
+	}
+
+	c.Files = append(c.Files, path)
+	c.mounts[path] = strings.ToLower(key)
+	mergeMaps(c.Raw, data, "", path, c.sources, c.mounts[path])
+	return nil
+}
+
+// ReadFile decodes a TOML, YAML or JSON file into a map
+func ReadFile(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]interface{})
+	switch configFormat(path) {
+	case "toml":
+		_, err = toml.Decode(string(content), &data)
+	case "yaml":
+		err = yaml.Unmarshal(content, &data)
+	case "json":
+		err = json.Unmarshal(content, &data)
+	default:
+		err = fmt.Errorf("unsupported config format")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// configFormat returns the format of a config file from its extension
+func configFormat(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".toml":
+		return "toml"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	}
+	return ""
+}
+
+func isRootConfig(name string) bool {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	return base == "hugo" || base == "config"
+}
+
+// mergeMaps merges src into dst. Keys are matched case insensitively, as
+// Hugo does, and maps are merged recursively. Keys at or below mount are
+// recorded in sources as coming from file.
+func mergeMaps(dst, src map[string]interface{}, prefix, file string, sources map[string]string, mount string) {
+	for k, v := range src {
+		path := prefix + strings.ToLower(k)
+		if mount == "" || strings.HasPrefix(path+".", mount+".") {
+			sources[path] = file
+		}
+
+		existing, key := lookup(dst, k)
+		dm, dok := asMap(existing)
+		if sm, sok := asMap(v); sok {
+			if !dok {
+				// A new map, filled key by key so their sources are known
+				if key != "" {
+					delete(dst, key)
+				}
+				key, dm = k, make(map[string]interface{})
+			}
+			dst[key] = dm
+			mergeMaps(dm, sm, path+".", file, sources, mount)
+			continue
+		}
+		if key != "" && key != k {
+			delete(dst, key)
+		}
+		dst[k] = v
+	}
+}
+
+// resolve fills in the typed fields from Raw, with Hugo's defaults
+func (c *SiteConfig) resolve() {
+	c.Title = getString(c.Raw, "title")
+	c.BaseURL = getString(c.Raw, "baseURL")
+	c.LanguageCode = getString(c.Raw, "languageCode")
+	c.DefaultContentLanguage = getString(c.Raw, "defaultContentLanguage")
+	if c.DefaultContentLanguage == "" {
+		c.DefaultContentLanguage = "en"
+	}
+	c.DefaultContentLanguageInSubdir = getBool(c.Raw, "defaultContentLanguageInSubdir")
+	c.ContentDir = getString(c.Raw, "contentDir")
+	if c.ContentDir == "" {
+		c.ContentDir = "content"
+	}
+	c.Theme = getStrings(c.Raw, "theme")
+	c.UglyURLs = getBool(c.Raw, "uglyURLs")
+	c.SummaryLength = getInt(c.Raw, "summaryLength")
+	if c.SummaryLength == 0 {
+		c.SummaryLength = 70
+	}
+
+	c.Params = getMap(c.Raw, "params")
+	slugs, _ := Get(c.Params, "bayan.slugs")
+	c.TransliterateSlugs = slugs == "transliterate"
+	c.Menus = readMenus(getMap(c.Raw, "menus"))
+	if len(c.Menus) == 0 {
+		c.Menus = readMenus(getMap(c.Raw, "menu"))
+	}
+
+	c.Taxonomies = map[string]string{"tag": "tags", "category": "categories"}
+	if v, key := lookup(c.Raw, "taxonomies"); key != "" {
+		c.Taxonomies = make(map[string]string)
+		if m, ok := asMap(v); ok {
+			for singular, plural := range m {
+				c.Taxonomies[singular] = fmt.Sprint(plural)
+			}
+		}
+	}
+
+	c.Permalinks = make(map[string]string)
+	permalinks := getMap(c.Raw, "permalinks")
+	if page := getMap(permalinks, "page"); len(page) > 0 {
+		permalinks = page
+	}
+	for section, pattern := range permalinks {
+		if s, ok := pattern.(string); ok {
+			c.Permalinks[section] = s
+		}
+	}
+
+	c.Languages = nil
+	for code, v := range getMap(c.Raw, "languages") {
+		m, ok := asMap(v)
+		if !ok {
+			continue
+		}
+		lang := Language{
+			Code:              code,
+			Name:              getString(m, "languageName"),
+			Title:             getString(m, "title"),
+			Weight:            getInt(m, "weight"),
+			ContentDir:        getString(m, "contentDir"),
+			LanguageCode:      getString(m, "languageCode"),
+			LanguageDirection: getString(m, "languageDirection"),
+			Params:            getMap(m, "params"),
+			Menus:             readMenus(getMap(m, "menus")),
+		}
+		if lang.ContentDir == "" {
+			lang.ContentDir = c.ContentDir
+		}
+		if lang.Title == "" {
+			lang.Title = c.Title
+		}
+		c.Languages = append(c.Languages, lang)
+	}
+	if len(c.Languages) == 0 {
+		c.Languages = []Language{{
+			Code:         c.DefaultContentLanguage,
+			Title:        c.Title,
+			ContentDir:   c.ContentDir,
+			LanguageCode: c.LanguageCode,
+			Params:       c.Params,
+			Menus:        c.Menus,
+		}}
+	}
+	sort.SliceStable(c.Languages, func(i, j int) bool {
+		if c.Languages[i].Weight != c.Languages[j].Weight {
+			return c.Languages[i].Weight < c.Languages[j].Weight
+		}
+		return c.Languages[i].Code < c.Languages[j].Code
+	})
+}
+
+func readMenus(m map[string]interface{}) map[string][]MenuEntry {
+	menus := make(map[string][]MenuEntry)
+	for name, v := range m {
+		for _, item := range asList(v) {
+			entry, ok := asMap(item)
+			if !ok {
+				continue
+			}
+			menus[name] = append(menus[name], readMenuEntry(entry))
+		}
+	}
+	return menus
+}
+
+// Multilingual reports whether the site defines more than one language
+func (c *SiteConfig) Multilingual() bool {
+	return len(c.Languages) > 1
+}
+
+// Language returns the language with the given code
+func (c *SiteConfig) Language(code string) (Language, bool) {
+	for _, lang := range c.Languages {
+		if strings.EqualFold(lang.Code, code) {
+			return lang, true
+		}
+	}
+	return Language{}, false
+}
+
+// ContentPath returns the absolute path of the content directory
+func (c *SiteConfig) ContentPath() string {
+	return filepath.Join(c.Dir, c.ContentDir)
+}
+
+// ThemesDir returns the absolute path of the directory holding themes
+func (c *SiteConfig) ThemesDir() string {
+	themesDir := getString(c.Raw, "themesDir")
+	if themesDir == "" {
+		themesDir = "themes"
+	}
+	return filepath.Join(c.Dir, themesDir)
+}
+
+// ThemePaths returns the directories of the site's themes
+func (c *SiteConfig) ThemePaths() []string {
+	paths := make([]string, len(c.Theme))
+	for i, theme := range c.Theme {
+		paths[i] = filepath.Join(c.ThemesDir(), theme)
+	}
+	return paths
+}
+
+// SourceOf returns the config file that set the dotted key, or its closest
+// parent. It is empty when no file sets it.
+func (c *SiteConfig) SourceOf(key string) string {
+	key = strings.ToLower(key)
+	for key != "" {
+		if file, ok := c.sources[key]; ok {
+			return file
+		}
+		i := strings.LastIndexByte(key, '.')
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSite creates the files of a site, by path relative to its root, in
+// a temporary directory
+func writeSite(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		environment string
+		check       func(t *testing.T, c *SiteConfig)
+	}{
+		{
+			name:  "no config",
+			files: map[string]string{"content/_index.md": ""},
+			check: func(t *testing.T, c *SiteConfig) {
+				if c.ContentDir != "content" || c.DefaultContentLanguage != "en" || c.SummaryLength != 70 {
+					t.Errorf("defaults = %q %q %d", c.ContentDir, c.DefaultContentLanguage, c.SummaryLength)
+				}
+				if len(c.Languages) != 1 || c.Languages[0].Code != "en" {
+					t.Errorf("languages = %v", c.Languages)
+				}
+			},
+		},
+		{
+			name: "hugo.toml before config.toml",
+			files: map[string]string{
+				"hugo.toml":   `title = "Hugo"`,
+				"config.toml": `title = "Config"`,
+			},
+			check: func(t *testing.T, c *SiteConfig) {
+				if c.Title != "Hugo" {
+					t.Errorf("title = %q", c.Title)
+				}
+				if c.MainFile() != filepath.Join(c.Dir, "hugo.toml") {
+					t.Errorf("main file = %q", c.MainFile())
+				}
+			},
+		},
+		{
+			name: "yaml root file",
+			files: map[string]string{
+				"hugo.yaml": "title: YAML\nbaseURL: https://example.org/\n",
+			},
+			check: func(t *testing.T, c *SiteConfig) {
+				if c.Title != "YAML" || c.BaseURL != "https://example.org/" {
+					t.Errorf("title, baseURL = %q, %q", c.Title, c.BaseURL)
+				}
+			},
+		},
+		{
+			name: "default and environment directories",
+			files: map[string]string{
+				"hugo.toml":                       "title = \"Root\"\nbaseURL = \"/\"\n",
+				"config/_default/hugo.toml":       `title = "Default"`,
+				"config/_default/params.toml":     "author = \"Ann\"\ncolor = \"red\"\n",
+				"config/production/params.toml":   `color = "blue"`,
+				"config/development/params.toml":  `color = "green"`,
+				"config/production/hugo.toml":     `baseURL = "https://example.org/"`,
+				"config/_default/permalinks.yaml": "posts: /:year/:slug/\n",
+			},
+			check: func(t *testing.T, c *SiteConfig) {
+				if c.Title != "Default" || c.BaseURL != "https://example.org/" {
+					t.Errorf("title, baseURL = %q, %q", c.Title, c.BaseURL)
+				}
+				if c.Params["author"] != "Ann" || c.Params["color"] != "blue" {
+					t.Errorf("params = %v", c.Params)
+				}
+				if c.Permalinks["posts"] != "/:year/:slug/" {
+					t.Errorf("permalinks = %v", c.Permalinks)
+				}
+				if got := c.SourceOf("params.color"); got != filepath.Join(c.Dir, "config", "production", "params.toml") {
+					t.Errorf("params.color comes from %q", got)
+				}
+			},
+		},
+		{
+			name: "development environment",
+			files: map[string]string{
+				"config/_default/params.toml":    `color = "red"`,
+				"config/development/params.toml": `color = "green"`,
+			},
+			environment: "development",
+			check: func(t *testing.T, c *SiteConfig) {
+				if c.Params["color"] != "green" {
+					t.Errorf("params = %v", c.Params)
+				}
+			},
+		},
+		{
+			name: "language files",
+			files: map[string]string{
+				"config/_default/hugo.toml":      "title = \"Site\"\ndefaultContentLanguage = \"en\"\n",
+				"config/_default/hugo.en.toml":   "title = \"English\"\nweight = 1\n",
+				"config/_default/hugo.ar.toml":   "title = \"عربي\"\nweight = 2\nlanguageDirection = \"rtl\"\ncontentDir = \"content/ar\"\n",
+				"config/_default/params.ar.toml": `author = "علي"`,
+				"config/_default/menus.en.toml":  "[[main]]\nname = \"Home\"\nurl = \"/\"\n",
+			},
+			check: func(t *testing.T, c *SiteConfig) {
+				if len(c.Languages) != 2 || c.Languages[0].Code != "en" || c.Languages[1].Code != "ar" {
+					t.Fatalf("languages = %v", c.Languages)
+				}
+				en, ar := c.Languages[0], c.Languages[1]
+				if en.Title != "English" || len(en.Menus["main"]) != 1 || en.Menus["main"][0].Name != "Home" {
+					t.Errorf("en = %+v", en)
+				}
+				if ar.Title != "عربي" || ar.LanguageDirection != "rtl" || ar.ContentDir != "content/ar" || ar.Params["author"] != "علي" {
+					t.Errorf("ar = %+v", ar)
+				}
+				if _, ok := c.Raw["languages"].(map[string]interface{})["en"].(map[string]interface{})["hugo"]; ok {
+					t.Errorf("hugo.en.toml was nested under languages.en.hugo")
+				}
+				if got := c.SourceOf("languages.ar.weight"); got != filepath.Join(c.Dir, "config", "_default", "hugo.ar.toml") {
+					t.Errorf("languages.ar.weight comes from %q", got)
+				}
+			},
+		},
+		{
+			name: "keys match case insensitively",
+			files: map[string]string{
+				"hugo.toml":                   "[Params]\nAuthor = \"Ann\"\n",
+				"config/_default/params.toml": `author = "Bob"`,
+			},
+			check: func(t *testing.T, c *SiteConfig) {
+				if len(c.Params) != 1 || c.Params["author"] != "Bob" {
+					t.Errorf("params = %v", c.Params)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Load(writeSite(t, tt.files), tt.environment)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			tt.check(t, c)
+		})
+	}
+}
+
+func TestSaveLanguageFile(t *testing.T) {
+	dir := writeSite(t, map[string]string{
+		"config/_default/hugo.toml":    `title = "Site"`,
+		"config/_default/hugo.ar.toml": "# Arabic\ntitle = \"عربي\"\nweight = 2\n",
+	})
+	c, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Save(map[string]interface{}{"languages.ar.weight": int64(3)}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "config", "_default", "hugo.ar.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Arabic\ntitle = \"عربي\"\nweight = 3\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if lang, _ := c.Language("ar"); lang.Weight != 3 {
+		t.Errorf("reloaded weight = %d", lang.Weight)
+	}
+}
@@ -0,0 +1,353 @@
+package site
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+)
+
+// Page is a content file of the site with its front matter
+type Page struct {
+	Path string // Absolute path of the file
+	Rel  string // Path relative to its content directory, with forward slashes
+	Lang string // Code of the language the page is in
+	Meta map[string]interface{}
+	Body string
+
+	key      string // Rel without the language of the file name
+	bodyLine int    // Lines of the file before Body, those of the front matter
+}
+
+// pageCache keeps the content files read by Pages, so that only those
+// changed since are read again
+type pageCache struct {
+	mu    sync.Mutex
+	files map[string]cachedPage // By path
+}
+
+type cachedPage struct {
+	modTime time.Time
+	size    int64
+	meta    map[string]interface{}
+	body    string
+	line    int // The line Body starts after
+}
+
+// Pages reads the front matter of every content file of the site, for all
+// languages. Files that can't be parsed are skipped. Files are only read
+// again when they changed since the last call, so the pages must not be
+// modified in place.
+//
+// A page is in the language of its file name, e.g. post.ar.md, or else in
+// the language of its content directory. When languages share a directory
+// the default language is used.
+func (c *SiteConfig) Pages() ([]*Page, error) {
+	cache := c.pages
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	found := make(map[string]bool, len(cache.files))
+	pages, err := c.walkPages(func(path string, d fs.DirEntry) (*Page, error) {
+		info, err := d.Info()
+		if err != nil {
+			return nil, err
+		}
+		found[path] = true
+		if f, ok := cache.files[path]; ok && f.modTime.Equal(info.ModTime()) && f.size == info.Size() {
+			return &Page{Path: path, Meta: f.meta, Body: f.body, bodyLine: f.line}, nil
+		}
+		p, err := ReadPage(path)
+		if err != nil {
+			delete(cache.files, path)
+			return nil, err
+		}
+		cache.files[path] = cachedPage{modTime: info.ModTime(), size: info.Size(), meta: p.Meta, body: p.Body, line: p.bodyLine}
+		return p, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for path := range cache.files {
+		if !found[path] {
+			delete(cache.files, path)
+		}
+	}
+	return pages, nil
+}
+
+// Forget makes Pages read the file at path again, for writes too quick
+// for its modification time to tell
+func (c *SiteConfig) Forget(path string) {
+	c.pages.mu.Lock()
+	delete(c.pages.files, path)
+	c.pages.mu.Unlock()
+}
+
+// walkPages finds the content files of the site like Pages, reading them
+// with read. Files read returns an error for are skipped.
+func (c *SiteConfig) walkPages(read func(path string, d fs.DirEntry) (*Page, error)) ([]*Page, error) {
+	dirs := make(map[string]string) // content dir -> its language
+	var order []string
+	for _, lang := range c.Languages {
+		dir := filepath.Join(c.Dir, lang.ContentDir)
+		if _, ok := dirs[dir]; !ok {
+			order = append(order, dir)
+			dirs[dir] = lang.Code
+		}
+		if lang.Code == c.DefaultContentLanguage {
+			dirs[dir] = lang.Code
+		}
+	}
+
+	var pages []*Page
+	for _, dir := range order {
+		var leaf string // The leaf bundle being walked, its other files are resources
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == dir {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if d.IsDir() {
+				if path != dir && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				if _, other := dirs[path]; other && path != dir {
+					return filepath.SkipDir // The content of another language
+				}
+				if leaf == "" || !strings.HasPrefix(path, leaf+string(filepath.Separator)) {
+					leaf = ""
+					if cms.BundleIndex(path, false) != "" {
+						leaf = path
+					}
+				}
+				return nil
+			}
+			if !isContentFile(d.Name()) {
+				return nil
+			}
+			inLeaf := leaf != "" && strings.HasPrefix(path, leaf+string(filepath.Separator))
+			if isIndex, _ := cms.IsBundleIndex(path); inLeaf && !(isIndex && filepath.Dir(path) == leaf) {
+				return nil
+			}
+
+			page, err := read(path, d)
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(dir, path)
+			page.Rel = filepath.ToSlash(rel)
+			page.Lang = dirs[dir]
+			page.key = page.Rel
+			if lang, base := c.SplitLang(d.Name()); lang != "" {
+				page.Lang = lang
+				page.key = filepath.ToSlash(filepath.Join(filepath.Dir(rel), base))
+			}
+			pages = append(pages, page)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Path < pages[j].Path })
+	return pages, nil
+}
+
+// SplitLang splits the language off a file name such as post.ar.md,
+// returning "ar" and post.md. The language is empty when the name has none
+// of the site's languages.
+func (c *SiteConfig) SplitLang(name string) (lang, base string) {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	i := strings.LastIndexByte(stem, '.')
+	if i < 0 {
+		return "", name
+	}
+	if l, ok := c.Language(stem[i+1:]); ok {
+		return l.Code, stem[:i] + ext
+	}
+	return "", name
+}
+
+// LangOf returns the language of the content at path, from its name or
+// else the content directory it is in
+func (c *SiteConfig) LangOf(path string) string {
+	if lang, _ := c.SplitLang(filepath.Base(path)); lang != "" {
+		return lang
+	}
+	code, longest := c.DefaultContentLanguage, -1
+	for _, lang := range c.Languages {
+		dir := filepath.Join(c.Dir, lang.ContentDir)
+		if path != dir && !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			continue
+		}
+		if len(dir) > longest || len(dir) == longest && lang.Code == c.DefaultContentLanguage {
+			code, longest = lang.Code, len(dir)
+		}
+	}
+	return code
+}
+
+// ArchetypeSite returns what an archetype sees as .Site when creating the
+// content at path: the settings of the site in the language of the page,
+// whose params override the site's.
+func (c *SiteConfig) ArchetypeSite(path string) cms.ArchetypeSite {
+	site := cms.ArchetypeSite{
+		Title:        c.Title,
+		BaseURL:      c.BaseURL,
+		LanguageCode: c.LanguageCode,
+		Params:       make(map[string]interface{}, len(c.Params)),
+	}
+	for k, v := range c.Params {
+		site.Params[k] = v
+	}
+
+	lang, ok := c.Language(c.LangOf(path))
+	if !ok {
+		return site
+	}
+	site.Title = lang.Title
+	if lang.LanguageCode != "" {
+		site.LanguageCode = lang.LanguageCode
+	}
+	for k, v := range lang.Params {
+		site.Params[k] = v
+	}
+	site.Language = cms.ArchetypeLanguage{
+		Lang:              lang.Code,
+		LanguageName:      lang.Name,
+		LanguageCode:      site.LanguageCode,
+		LanguageDirection: lang.LanguageDirection,
+		Weight:            lang.Weight,
+	}
+	return site
+}
+
+// ReadPage reads a content file
+func ReadPage(path string) (*Page, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	md, err := cms.ParseMD(string(content))
+	if err != nil {
+		return nil, err
+	}
+	if md.MetaData == nil {
+		md.MetaData = make(map[string]interface{})
+	}
+	line := 0
+	if strings.HasSuffix(string(content), md.Body) {
+		line = strings.Count(string(content[:len(content)-len(md.Body)]), "\n")
+	}
+	return &Page{Path: path, Meta: md.MetaData, Body: md.Body, bodyLine: line}, nil
+}
+
+// UpdatePage rewrites the front matter of a content file with edit,
+// keeping the rest of the file as it is.
+func UpdatePage(path string, edit func(meta map[string]interface{}) error) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	md, err := cms.ParseMD(string(content))
+	if err != nil {
+		return err
+	}
+	if md.MetaData == nil {
+		md.MetaData = make(map[string]interface{})
+	}
+	if err := edit(md.MetaData); err != nil {
+		return err
+	}
+
+	out, err := md.ToString()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(out), 0644)
+}
+
+func isContentFile(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".md")
+}
+
+// Title returns the title of the page
+func (p *Page) Title() string {
+	if title := getString(p.Meta, "title"); title != "" {
+		return title
+	}
+	return strings.TrimSuffix(filepath.Base(p.Path), filepath.Ext(p.Path))
+}
+
+// LinkTitle returns linkTitle, falling back to the title, as Hugo does for
+// menus and links
+func (p *Page) LinkTitle() string {
+	if title := getString(p.Meta, "linkTitle"); title != "" {
+		return title
+	}
+	return p.Title()
+}
+
+// Section returns the top-level directory of the page, empty for pages at
+// the root of the content directory
+func (p *Page) Section() string {
+	if section, _, ok := strings.Cut(p.Rel, "/"); ok {
+		return section
+	}
+	return ""
+}
+
+// Ref returns the path of the page as used by pageRef and ref, e.g.
+// /posts/hello for posts/hello.md or posts/hello/index.md
+func (p *Page) Ref() string {
+	key := p.keyPath()
+	ref := strings.TrimSuffix(key, filepath.Ext(key))
+	ref = strings.TrimSuffix(ref, "/index")
+	ref = strings.TrimSuffix(ref, "/_index")
+	if ref == "index" || ref == "_index" {
+		ref = ""
+	}
+	return "/" + ref
+}
+
+// TranslationKey returns what the translations of a page have in common:
+// the translationKey front matter, or the path without the language
+func (p *Page) TranslationKey() string {
+	if key := getString(p.Meta, "translationKey"); key != "" {
+		return "key:" + key
+	}
+	return p.keyPath()
+}
+
+// keyPath returns the path of the page without its language
+func (p *Page) keyPath() string {
+	if p.key != "" {
+		return p.key
+	}
+	return p.Rel
+}
+
+// Get returns the front matter value at a dotted key, ignoring case
+func (p *Page) Get(key string) (interface{}, bool) {
+	return Get(p.Meta, key)
+}
+
+// IsCJKLanguage reports whether Hugo reads p as Chinese, Japanese or
+// Korean, which changes how it counts words: the isCJKLanguage front
+// matter, else hasCJKLanguage in the config and CJK in the content
+func (c *SiteConfig) IsCJKLanguage(p *Page) bool {
+	if _, key := lookup(p.Meta, "isCJKLanguage"); key != "" {
+		return getBool(p.Meta, "isCJKLanguage")
+	}
+	return getBool(c.Raw, "hasCJKLanguage") && cms.HasCJK(p.Body)
+}
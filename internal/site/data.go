@@ -0,0 +1,67 @@
+package site
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+)
+
+// DataDir returns the absolute path of the directory holding data files
+func (c *SiteConfig) DataDir() string {
+	dataDir := getString(c.Raw, "dataDir")
+	if dataDir == "" {
+		dataDir = "data"
+	}
+	return filepath.Join(c.Dir, dataDir)
+}
+
+// DataFiles lists the TOML, YAML and JSON files of the data directory,
+// relative to it with forward slashes
+func (c *SiteConfig) DataFiles() ([]string, error) {
+	root := c.DataDir()
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != root {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && configFormat(d.Name()) != "" {
+			rel, _ := filepath.Rel(root, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// ReadData reads a data file so it can be edited and written back in its
+// own format
+func ReadData(path string) (*cms.DataFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return cms.ParseData(string(content), configFormat(path))
+}
+
+// WriteData writes an edited data file back
+func WriteData(path string, d *cms.DataFile) error {
+	out, err := d.ToString()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(out), 0644)
+}
@@ -0,0 +1,322 @@
+package site
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+)
+
+// I18n holds the translation strings of the site for each language: those
+// of its i18n directory, which it edits, and those of its themes, which
+// the site's override
+type I18n struct {
+	Langs []string // Codes of the site's languages
+	IDs   []string // Every ID of a file or a template, sorted
+
+	files  map[string]*i18nFile          // lang -> file of the site
+	themes map[string]map[string]Strings // lang -> id -> forms, from themes
+	used   map[string]bool               // IDs used in templates
+	plural map[string]bool               // IDs made plural before any form is set
+}
+
+// Strings are the forms of a translation: "other" alone for a plain
+// string, or the plural forms such as "one" and "other"
+type Strings map[string]string
+
+type i18nFile struct {
+	path    string
+	data    *cms.DataFile
+	changed bool
+}
+
+// pluralForms are the CLDR plural categories of languages, by the base of
+// their code. Other languages use one and other.
+var pluralForms = map[string][]string{
+	"ar": {"zero", "one", "two", "few", "many", "other"},
+	"cs": {"one", "few", "many", "other"},
+	"cy": {"zero", "one", "two", "few", "many", "other"},
+	"fr": {"one", "many", "other"},
+	"ga": {"one", "two", "few", "many", "other"},
+	"he": {"one", "two", "other"},
+	"id": {"other"},
+	"it": {"one", "many", "other"},
+	"ja": {"other"},
+	"ko": {"other"},
+	"lt": {"one", "few", "many", "other"},
+	"ms": {"other"},
+	"pl": {"one", "few", "many", "other"},
+	"pt": {"one", "many", "other"},
+	"ru": {"one", "few", "many", "other"},
+	"sk": {"one", "few", "many", "other"},
+	"th": {"other"},
+	"uk": {"one", "few", "many", "other"},
+	"vi": {"other"},
+	"zh": {"other"},
+}
+
+// PluralForms returns the plural forms a language distinguishes
+func PluralForms(lang string) []string {
+	base, _, _ := strings.Cut(strings.ToLower(lang), "-")
+	if forms, ok := pluralForms[base]; ok {
+		return forms
+	}
+	return []string{"one", "other"}
+}
+
+var i18nUse = regexp.MustCompile("(?:\\b(?:i18n|T|lang\\.Translate)\\s+[\"`]([^\"`]+)[\"`])|(?:[\"`]([^\"`]+)[\"`]\\s*\\|\\s*(?:i18n|T)\\b)")
+
+// LoadI18n reads the translation strings of the site and its themes, and
+// the IDs its templates use
+func (c *SiteConfig) LoadI18n() (*I18n, error) {
+	t := &I18n{
+		files:  make(map[string]*i18nFile),
+		themes: make(map[string]map[string]Strings),
+		used:   make(map[string]bool),
+		plural: make(map[string]bool),
+	}
+	ids := make(map[string]bool)
+
+	for _, lang := range c.Languages {
+		t.Langs = append(t.Langs, lang.Code)
+
+		path := findI18nFile(filepath.Join(c.Dir, "i18n"), lang.Code)
+		f := &i18nFile{path: path}
+		if path == "" {
+			f.path = filepath.Join(c.Dir, "i18n", lang.Code+".toml")
+			f.data, _ = cms.ParseData("", "toml")
+		} else {
+			data, err := ReadData(path)
+			if err != nil {
+				return nil, err
+			}
+			f.data = data
+		}
+		if f.data.Data == nil {
+			// The older format is a list of { id, translation }, written
+			// back as a map once edited
+			f.data.Data = make(map[string]interface{})
+			for _, item := range f.data.List {
+				if m, ok := asMap(item); ok && getString(m, "id") != "" {
+					tr, _ := lookup(m, "translation")
+					f.data.Data[getString(m, "id")] = tr
+				}
+			}
+			f.data.List = nil
+		}
+		t.files[lang.Code] = f
+		for id := range f.data.Data {
+			ids[id] = true
+		}
+
+		t.themes[lang.Code] = make(map[string]Strings)
+		for _, theme := range c.ThemePaths() {
+			path := findI18nFile(filepath.Join(theme, "i18n"), lang.Code)
+			if path == "" {
+				continue
+			}
+			data, err := ReadData(path)
+			if err != nil {
+				continue
+			}
+			for id, v := range data.Data {
+				if _, ok := t.themes[lang.Code][id]; !ok {
+					t.themes[lang.Code][id] = readStrings(v)
+					ids[id] = true
+				}
+			}
+		}
+	}
+
+	for _, dir := range append([]string{c.Dir}, c.ThemePaths()...) {
+		filepath.WalkDir(filepath.Join(dir, "layouts"), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			for _, m := range i18nUse.FindAllStringSubmatch(string(content), -1) {
+				id := m[1] + m[2]
+				t.used[id] = true
+				ids[id] = true
+			}
+			return nil
+		})
+	}
+
+	for id := range ids {
+		t.IDs = append(t.IDs, id)
+	}
+	sort.Strings(t.IDs)
+	return t, nil
+}
+
+// findI18nFile returns the file of dir for a language, matching its code
+// without case, e.g. en-US.toml for en-us
+func findI18nFile(dir, lang string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && configFormat(name) != "" && strings.EqualFold(strings.TrimSuffix(name, filepath.Ext(name)), lang) {
+			return filepath.Join(dir, name)
+		}
+	}
+	return ""
+}
+
+// readStrings reads a translation: a string, or a map of plural forms
+// which may also hold a description
+func readStrings(v interface{}) Strings {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return Strings{"other": v}
+	}
+	m, ok := asMap(v)
+	if !ok {
+		return Strings{"other": fmt.Sprint(v)}
+	}
+	s := make(Strings)
+	for _, form := range []string{"zero", "one", "two", "few", "many", "other"} {
+		if text := getString(m, form); text != "" {
+			s[form] = text
+		}
+	}
+	return s
+}
+
+// Get returns the translation of id the site gives lang, and whether it
+// comes from a theme
+func (t *I18n) Get(lang, id string) (s Strings, inherited bool) {
+	if f, ok := t.files[lang]; ok {
+		if v, ok := f.data.Data[id]; ok {
+			return readStrings(v), false
+		}
+	}
+	if s, ok := t.themes[lang][id]; ok {
+		return s, true
+	}
+	return nil, false
+}
+
+// Plural reports whether id has plural forms in any language. A table
+// with only other, as TOML files often have, is a plain string.
+func (t *I18n) Plural(id string) bool {
+	if t.plural[id] {
+		return true
+	}
+	for _, lang := range t.Langs {
+		s, _ := t.Get(lang, id)
+		for form := range s {
+			if form != "other" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Missing returns the forms of id lang lacks: all of them when it has no
+// translation, else the plural forms of the language it doesn't give
+func (t *I18n) Missing(lang, id string) []string {
+	s, _ := t.Get(lang, id)
+	forms := []string{"other"}
+	if t.Plural(id) {
+		forms = PluralForms(lang)
+	}
+	var missing []string
+	for _, form := range forms {
+		if s[form] == "" {
+			missing = append(missing, form)
+		}
+	}
+	return missing
+}
+
+// MakePlural gives id plural forms in every language
+func (t *I18n) MakePlural(id string) {
+	t.plural[id] = true
+}
+
+// Used reports whether a template uses id
+func (t *I18n) Used(id string) bool {
+	return t.used[id]
+}
+
+// Set changes a form of the translation of id in the site's file for
+// lang. A plain string becomes a map once it gets plural forms, and an
+// empty text removes the form.
+func (t *I18n) Set(lang, id, form, text string) {
+	f, ok := t.files[lang]
+	if !ok {
+		return
+	}
+	f.changed = true
+
+	cur := f.data.Data[id]
+	m, isMap := asMap(cur)
+	if !isMap && form == "other" && !t.Plural(id) {
+		if text == "" {
+			delete(f.data.Data, id)
+		} else {
+			f.data.Data[id] = text
+		}
+		return
+	}
+
+	if !isMap {
+		m = make(map[string]interface{})
+		if s, ok := cur.(string); ok && s != "" {
+			m["other"] = s
+		}
+	}
+	if text == "" {
+		delete(m, form)
+	} else {
+		m[form] = text
+	}
+	if len(m) == 0 {
+		delete(f.data.Data, id)
+		return
+	}
+	f.data.Data[id] = m
+}
+
+// Add adds an ID without translations, so it can be filled in
+func (t *I18n) Add(id string) {
+	i := sort.SearchStrings(t.IDs, id)
+	if i < len(t.IDs) && t.IDs[i] == id {
+		return
+	}
+	t.IDs = append(t.IDs[:i], append([]string{id}, t.IDs[i:]...)...)
+}
+
+// Save writes the files of the languages that were changed, creating the
+// i18n directory when needed
+func (t *I18n) Save() error {
+	for _, lang := range t.Langs {
+		f := t.files[lang]
+		if f == nil || !f.changed {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+			return err
+		}
+		if err := WriteData(f.path, f.data); err != nil {
+			return err
+		}
+		f.changed = false
+	}
+	return nil
+}
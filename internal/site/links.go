@@ -0,0 +1,308 @@
+package site
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// BrokenLink is a link in the content of a page that leads nowhere
+type BrokenLink struct {
+	Page   *Page
+	Line   int    // Line of the file, from 1
+	Kind   string // "ref", "relref", "link" or "image"
+	Target string // As written
+	Reason string
+}
+
+var (
+	refShortcode = regexp.MustCompile(`\{\{[<%]\s*(ref|relref)\s+(.*?)\s*/?[>%]\}\}`)
+	refArg       = regexp.MustCompile("(?:(\\w+)\\s*=\\s*)?(?:\"((?:[^\"\\\\]|\\\\.)*)\"|`([^`]*)`|([^\\s\"`]+))")
+	mdLink       = regexp.MustCompile(`(!?)\[(?:[^\[\]]|\[[^\]]*\])*\]\(\s*<?([^)\s>]*)>?(?:\s+(?:"[^"]*"|'[^']*'))?\s*\)`)
+	mdLinkDef    = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s*<?(\S+?)>?(?:\s|$)`)
+	codeSpan     = regexp.MustCompile("`[^`]*`")
+	urlScheme    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+)
+
+// CheckLinks checks the links of every page of the site
+func (c *SiteConfig) CheckLinks(pages []*Page) []BrokenLink {
+	index := c.newContentIndex(pages)
+	var broken []BrokenLink
+	for _, p := range pages {
+		broken = append(broken, c.checkPage(index, p)...)
+	}
+	return broken
+}
+
+// CheckPage checks the ref and relref shortcodes of a page, and its links
+// and images: they must lead to a page, or to a file next to it or in the
+// static directories. Absolute links must be the URL of a page.
+func (c *SiteConfig) CheckPage(pages []*Page, p *Page) []BrokenLink {
+	return c.checkPage(c.newContentIndex(pages), p)
+}
+
+func (c *SiteConfig) checkPage(index contentIndex, p *Page) []BrokenLink {
+	var broken []BrokenLink
+	report := func(line int, kind, target, reason string) {
+		broken = append(broken, BrokenLink{Page: p, Line: p.bodyLine + line, Kind: kind, Target: target, Reason: reason})
+	}
+
+	fence := ""
+	for i, line := range strings.Split(p.Body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		for _, m := range refShortcode.FindAllStringSubmatch(line, -1) {
+			target, lang := refTarget(m[2])
+			if lang == "" {
+				lang = p.Lang
+			}
+			if reason := index.checkRef(p, target, lang); reason != "" {
+				report(i+1, m[1], target, reason)
+			}
+		}
+
+		line = codeSpan.ReplaceAllString(line, "")
+		for _, m := range mdLink.FindAllStringSubmatch(line, -1) {
+			kind := "link"
+			if m[1] == "!" {
+				kind = "image"
+			}
+			if reason := c.checkLink(index, p, m[2], kind); reason != "" {
+				report(i+1, kind, m[2], reason)
+			}
+		}
+		if m := mdLinkDef.FindStringSubmatch(line); m != nil {
+			if reason := c.checkLink(index, p, m[1], "link"); reason != "" {
+				report(i+1, "link", m[1], reason)
+			}
+		}
+	}
+	return broken
+}
+
+// refTarget reads the arguments of a ref or relref shortcode: the path,
+// by position or as path=, and the lang
+func refTarget(args string) (target, lang string) {
+	pos := 0
+	for _, m := range refArg.FindAllStringSubmatch(args, -1) {
+		val := m[2] + m[3] + m[4]
+		if m[2] != "" {
+			val = strings.ReplaceAll(val, `\"`, `"`)
+		}
+		switch strings.ToLower(m[1]) {
+		case "path":
+			target = val
+		case "lang":
+			lang = val
+		case "":
+			if pos == 0 {
+				target = val
+			}
+			pos++
+		}
+	}
+	return target, lang
+}
+
+// checkLink checks a Markdown link or image. Links to other sites and to
+// anchors of the page aren't checked. Absolute paths must be in a static
+// directory, or for links be the URL of a page or of a resource of it.
+func (c *SiteConfig) checkLink(index contentIndex, p *Page, target, kind string) string {
+	if target == "" || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "//") ||
+		strings.Contains(target, "{{") || urlScheme.MatchString(target) {
+		return ""
+	}
+	clean, _, _ := strings.Cut(target, "#")
+	clean, _, _ = strings.Cut(clean, "?")
+	if unescaped, err := url.PathUnescape(clean); err == nil {
+		clean = unescaped
+	}
+	if clean == "" {
+		return ""
+	}
+
+	if strings.HasPrefix(clean, "/") {
+		for _, dir := range c.StaticDirs() {
+			if exists(filepath.Join(dir, filepath.FromSlash(clean))) {
+				return ""
+			}
+		}
+		if kind == "image" {
+			return "not found in the static directories"
+		}
+		return index.checkURL(c, clean)
+	}
+
+	// A file next to the page, such as a resource of its bundle
+	if exists(filepath.Join(filepath.Dir(p.Path), filepath.FromSlash(clean))) {
+		return ""
+	}
+	// A page, the way Hugo's render hooks resolve links
+	if kind == "link" && index.checkRef(p, clean, p.Lang) == "" {
+		return ""
+	}
+	return "no such file or page"
+}
+
+// StaticDirs returns the directories whose files Hugo copies to the site
+// as they are: those of the site, then those of its themes
+func (c *SiteConfig) StaticDirs() []string {
+	names := getStrings(c.Raw, "staticDir")
+	if len(names) == 0 {
+		names = []string{"static"}
+	}
+	var dirs []string
+	for _, name := range names {
+		dirs = append(dirs, filepath.Join(c.Dir, name))
+	}
+	for _, theme := range c.ThemePaths() {
+		dirs = append(dirs, filepath.Join(theme, "static"))
+	}
+	return dirs
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// contentIndex finds pages by the paths ref accepts, for each language,
+// and by their URL
+type contentIndex struct {
+	paths map[string]map[string][]*Page // lang -> path without extension -> pages
+	names map[string]map[string][]*Page // lang -> last element of the path -> pages
+	urls  map[string]*Page              // URL path -> page, nil for lists of sections and terms
+}
+
+func (c *SiteConfig) newContentIndex(pages []*Page) contentIndex {
+	index := contentIndex{
+		paths: make(map[string]map[string][]*Page),
+		names: make(map[string]map[string][]*Page),
+		urls:  make(map[string]*Page),
+	}
+	for _, p := range pages {
+		// Hugo also makes lists of the sections above a page and of its
+		// terms, and redirects from its aliases
+		prefix := ""
+		if p.Lang != c.DefaultContentLanguage || c.DefaultContentLanguageInSubdir {
+			prefix = "/" + p.Lang
+		}
+		list := func(u string) {
+			if _, ok := index.urls[u]; !ok {
+				index.urls[u] = nil
+			}
+		}
+		list(prefix + "/")
+		for dir := path.Dir(p.keyPath()); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			list(strings.ToLower(prefix + "/" + dir + "/"))
+		}
+		for _, plural := range c.Taxonomies {
+			if terms := pageTerms(p.Meta, plural); len(terms) > 0 {
+				list(strings.ToLower(prefix + "/" + plural + "/"))
+				for _, term := range terms {
+					list(prefix + "/" + strings.ToLower(plural) + "/" + Urlize(term) + "/")
+				}
+			}
+		}
+		for _, alias := range c.Aliases(p) {
+			if index.urls[strings.ToLower(alias)] == nil {
+				index.urls[strings.ToLower(alias)] = p
+			}
+		}
+		index.urls[strings.ToLower(c.RelPermalink(p))] = p
+
+		if index.paths[p.Lang] == nil {
+			index.paths[p.Lang] = make(map[string][]*Page)
+			index.names[p.Lang] = make(map[string][]*Page)
+		}
+		stem := refPath(p.keyPath())
+		index.paths[p.Lang][stem] = append(index.paths[p.Lang][stem], p)
+		index.names[p.Lang][path.Base(stem)] = append(index.names[p.Lang][path.Base(stem)], p)
+	}
+	return index
+}
+
+// refPath returns a content path as ref matches it: lower case, without
+// extension, and without index or _index for bundles
+func refPath(p string) string {
+	p = strings.ToLower(strings.Trim(path.Clean("/"+p), "/"))
+	if ext := path.Ext(p); ext == ".md" {
+		p = strings.TrimSuffix(p, ext)
+	}
+	base := path.Base(p)
+	if base == "index" || base == "_index" {
+		p = path.Dir(p)
+	}
+	if p == "." {
+		return ""
+	}
+	return p
+}
+
+// checkURL finds the page at an absolute URL path, written from the root
+// of the site or of its baseURL. It also accepts the files of a bundle
+// under the URL of its page. It returns why the link is broken, or "".
+func (index contentIndex) checkURL(c *SiteConfig, target string) string {
+	if u, err := url.Parse(c.BaseURL); err == nil && strings.Trim(u.Path, "/") != "" {
+		target = "/" + strings.TrimPrefix(strings.TrimPrefix(target, strings.TrimRight(u.Path, "/")), "/")
+	}
+	target = strings.ToLower(target)
+	if path.Ext(target) == "" && !strings.HasSuffix(target, "/") {
+		target += "/"
+	}
+	if _, ok := index.urls[target]; ok {
+		return ""
+	}
+	if p := index.urls[path.Dir(target)+"/"]; p != nil {
+		if exists(filepath.Join(filepath.Dir(p.Path), filepath.FromSlash(path.Base(target)))) {
+			return ""
+		}
+	}
+	return "no page has this URL"
+}
+
+// checkRef finds the page a ref points to the way Hugo does: relative to
+// the page, then from the content root, then by its name if it is unique.
+// It returns why the ref is broken, or "".
+func (index contentIndex) checkRef(from *Page, target, lang string) string {
+	target, _, _ = strings.Cut(target, "#")
+	if target == "" {
+		return "" // An anchor of the page itself
+	}
+	paths, ok := index.paths[lang]
+	if !ok {
+		return "no content in language " + lang
+	}
+
+	if !strings.HasPrefix(target, "/") {
+		if _, ok := paths[refPath(path.Join(path.Dir(from.keyPath()), target))]; ok {
+			return ""
+		}
+	}
+	if _, ok := paths[refPath(target)]; ok {
+		return ""
+	}
+	if !strings.Contains(strings.Trim(target, "/"), "/") {
+		switch len(index.names[lang][path.Base(refPath(target))]) {
+		case 1:
+			return ""
+		case 0:
+		default:
+			return "more than one page has this name"
+		}
+	}
+	return "no such page"
+}
@@ -0,0 +1,103 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckPage(t *testing.T) {
+	dir := writeSite(t, map[string]string{
+		"hugo.toml":                     "baseURL = \"https://example.org/blog/\"\n",
+		"content/_index.md":             "---\ntitle: Home\n---\n",
+		"content/about.md":              "---\ntitle: About\naliases: [/old-about/]\n---\n",
+		"content/posts/hello.md":        "---\ntitle: Hello\ntags: [Go Tips]\n---\n## Intro\n",
+		"content/posts/trip/index.md":   "---\ntitle: Trip\n---\n",
+		"content/posts/trip/photo.jpg":  "",
+		"content/docs/guide/install.md": "---\ntitle: Install\nslug: setup\n---\n",
+		"static/images/logo.png":        "",
+	})
+	c, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := filepath.Join(dir, "content", "posts", "check.md")
+
+	tests := []struct {
+		name   string
+		line   string
+		broken string // The target reported, "" when none is
+	}{
+		{"ref", `{{< ref "posts/hello" >}}`, ""},
+		{"ref by name", `{{< ref "install" >}}`, ""},
+		{"ref with anchor", `{{< ref "/posts/hello.md#intro" >}}`, ""},
+		{"ref to a bundle", `{{< ref "trip" >}}`, ""},
+		{"broken ref", `{{< ref "posts/bye" >}}`, "posts/bye"},
+		{"relref", `{{% relref path="hello.md" %}}`, ""},
+		{"relref in a missing language", `{{< relref path="hello" lang="fr" >}}`, "hello"},
+		{"anchor", `[Top](#top)`, ""},
+		{"relative link to a page", `[Hello](hello.md)`, ""},
+		{"relative link up", `[About](../about.md)`, ""},
+		{"relative link to a resource", `![Photo](trip/photo.jpg)`, ""},
+		{"broken relative link", `[Gone](gone.md)`, "gone.md"},
+		{"external link", `[Hugo](https://gohugo.io/)`, ""},
+		{"absolute link to a page", `[About](/about/)`, ""},
+		{"absolute link with baseURL path", `[About](/blog/about/#team)`, ""},
+		{"absolute link to a slug", `[Install](/docs/guide/setup/)`, ""},
+		{"absolute link to an alias", `[Old](/old-about/)`, ""},
+		{"absolute link to a section", `[Posts](/posts/)`, ""},
+		{"absolute link to a term", `[Go](/tags/go-tips/)`, ""},
+		{"absolute link to a resource", `[Photo](/posts/trip/photo.jpg)`, ""},
+		{"absolute link to a static file", `[Logo](/images/logo.png)`, ""},
+		{"broken absolute link", `[Gone](/posts/gone/)`, "/posts/gone/"},
+		{"broken image", `![Logo](/images/none.png)`, "/images/none.png"},
+		{"link definition", `[gone]: /nowhere/`, "/nowhere/"},
+		{"code span", "`[Gone](/posts/gone/)`", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "---\ntitle: Check\n---\nIntro\n\n" + tt.line + "\n"
+			if err := os.WriteFile(check, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			c.Forget(check)
+			pages, err := c.Pages()
+			if err != nil {
+				t.Fatal(err)
+			}
+			broken := c.CheckPage(pages, FindPage(pages, check))
+
+			if tt.broken == "" {
+				for _, b := range broken {
+					t.Errorf("%s reported broken: %s", b.Target, b.Reason)
+				}
+				return
+			}
+			if len(broken) != 1 || broken[0].Target != tt.broken {
+				t.Fatalf("broken = %+v, want %s", broken, tt.broken)
+			}
+			if broken[0].Line != 6 {
+				t.Errorf("line = %d, want 6", broken[0].Line)
+			}
+		})
+	}
+}
+
+func TestCheckPageFence(t *testing.T) {
+	dir := writeSite(t, map[string]string{
+		"content/a.md": "+++\ntitle = 'A'\n+++\n```\n[Gone](gone.md)\n```\n[Gone](gone.md)\n",
+	})
+	c, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages, err := c.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken := c.CheckLinks(pages)
+	if len(broken) != 1 || broken[0].Line != 7 {
+		t.Errorf("broken = %+v, want the link on line 7 only", broken)
+	}
+}
@@ -0,0 +1,544 @@
+package site
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Menu is a menu of the site merged from the config and the front matter of
+// pages, as a tree of entries.
+type Menu struct {
+	Name  string
+	Lang  string
+	Items []*MenuItem // Top level entries, in menu order
+
+	site    *SiteConfig
+	lists   map[string][]*MenuItem // config key -> entries of the list, as written
+	origs   map[string][]interface{}
+	listKey string      // where new links go
+	pages   []*MenuItem // entries from front matter
+	removed []*MenuItem // page entries to drop from their page
+}
+
+// MenuItem is an entry of a menu
+type MenuItem struct {
+	MenuEntry
+	Page     *Page // The page whose front matter defines the entry, nil for config entries
+	Children []*MenuItem
+
+	parent *MenuItem
+	raw    map[string]interface{} // the entry as written, nil when it is new
+}
+
+// ID returns what children use as their parent: the identifier, or the
+// name when there is none
+func (i *MenuItem) ID() string {
+	if i.Identifier != "" {
+		return i.Identifier
+	}
+	return i.Label()
+}
+
+// Label returns the text of the entry, which defaults to the link title of
+// its page
+func (i *MenuItem) Label() string {
+	if i.Name == "" && i.Page != nil {
+		return i.Page.LinkTitle()
+	}
+	return i.Name
+}
+
+// Link returns where the entry points to
+func (i *MenuItem) Link() string {
+	switch {
+	case i.Page != nil:
+		return i.Page.Ref()
+	case i.PageRef != "":
+		return i.PageRef
+	}
+	return i.URL
+}
+
+// ParentItem returns the entry the item is nested under, nil at the top level
+func (i *MenuItem) ParentItem() *MenuItem {
+	return i.parent
+}
+
+// MenuNames returns the names of the menus defined in the config or in the
+// front matter of pages
+func (c *SiteConfig) MenuNames(pages []*Page) []string {
+	seen := make(map[string]bool)
+	for name := range c.Menus {
+		seen[name] = true
+	}
+	for _, lang := range c.Languages {
+		for name := range lang.Menus {
+			seen[name] = true
+		}
+	}
+	for _, p := range pages {
+		for name := range pageMenus(p.Meta) {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadMenu builds the menu called name for a language from the config and
+// the given pages.
+func (c *SiteConfig) LoadMenu(name, lang string, pages []*Page) *Menu {
+	m := &Menu{
+		Name:  name,
+		Lang:  lang,
+		site:  c,
+		lists: make(map[string][]*MenuItem),
+		origs: make(map[string][]interface{}),
+	}
+
+	// Menus of the language, then the ones for all languages
+	var keys []string
+	if _, ok := c.Language(lang); ok && c.Multilingual() {
+		keys = append(keys, "languages."+lang+".menus."+name)
+	}
+	keys = append(keys, "menus."+name, "menu."+name)
+
+	var items []*MenuItem
+	for _, key := range keys {
+		list, ok := Get(c.Raw, key)
+		if !ok {
+			continue
+		}
+		if m.listKey == "" {
+			m.listKey = key
+		}
+		m.origs[key] = asList(list)
+		for _, v := range asList(list) {
+			raw, ok := asMap(v)
+			if !ok {
+				continue
+			}
+			item := &MenuItem{MenuEntry: readMenuEntry(raw), raw: raw}
+			m.lists[key] = append(m.lists[key], item)
+			items = append(items, item)
+		}
+	}
+	if m.listKey == "" {
+		m.listKey = "menus." + name
+	}
+
+	for _, p := range pages {
+		if c.Multilingual() && p.Lang != lang {
+			continue
+		}
+		for menu, raw := range pageMenus(p.Meta) {
+			if menu != name {
+				continue
+			}
+			item := &MenuItem{MenuEntry: readMenuEntry(raw), Page: p, raw: raw}
+			m.pages = append(m.pages, item)
+			items = append(items, item)
+		}
+	}
+
+	// Nest the entries under their parent
+	byID := make(map[string]*MenuItem, len(items))
+	for _, item := range items {
+		if _, dup := byID[item.ID()]; !dup {
+			byID[item.ID()] = item
+		}
+	}
+	for _, item := range items {
+		parent := byID[item.Parent]
+		if item.Parent == "" || parent == nil || parent == item || isBelow(parent, item) {
+			m.Items = append(m.Items, item)
+			continue
+		}
+		item.parent = parent
+		parent.Children = append(parent.Children, item)
+	}
+	sortMenu(m.Items)
+
+	return m
+}
+
+func readMenuEntry(m map[string]interface{}) MenuEntry {
+	return MenuEntry{
+		Identifier: getString(m, "identifier"),
+		Name:       getString(m, "name"),
+		URL:        getString(m, "url"),
+		PageRef:    getString(m, "pageRef"),
+		Parent:     getString(m, "parent"),
+		Pre:        getString(m, "pre"),
+		Post:       getString(m, "post"),
+		Weight:     getInt(m, "weight"),
+		Params:     getMap(m, "params"),
+	}
+}
+
+// pageMenus reads the menu front matter of a page, which can be a menu
+// name, a list of names or a map of names to entries.
+func pageMenus(meta map[string]interface{}) map[string]map[string]interface{} {
+	v, key := lookup(meta, "menus")
+	if key == "" {
+		v, _ = lookup(meta, "menu")
+	}
+
+	menus := make(map[string]map[string]interface{})
+	if m, ok := asMap(v); ok {
+		for name, entry := range m {
+			raw, ok := asMap(entry)
+			if !ok {
+				raw = map[string]interface{}{}
+			}
+			menus[name] = raw
+		}
+		return menus
+	}
+	for _, name := range getStrings(map[string]interface{}{"menu": v}, "menu") {
+		menus[name] = map[string]interface{}{}
+	}
+	return menus
+}
+
+// sortMenu sorts entries the way Hugo does: by weight with unweighted ones
+// last, then by name
+func sortMenu(items []*MenuItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Weight != b.Weight {
+			if a.Weight == 0 || b.Weight == 0 {
+				return b.Weight == 0
+			}
+			return a.Weight < b.Weight
+		}
+		return a.Label() < b.Label()
+	})
+	for _, item := range items {
+		sortMenu(item.Children)
+	}
+}
+
+// isBelow reports whether item is nested somewhere under ancestor
+func isBelow(item, ancestor *MenuItem) bool {
+	for p := item.parent; p != nil; p = p.parent {
+		if p == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// Siblings returns the list item is in
+func (m *Menu) Siblings(item *MenuItem) []*MenuItem {
+	if item.parent != nil {
+		return item.parent.Children
+	}
+	return m.Items
+}
+
+func (m *Menu) setSiblings(parent *MenuItem, items []*MenuItem) {
+	if parent != nil {
+		parent.Children = items
+	} else {
+		m.Items = items
+	}
+	renumber(items)
+}
+
+// renumber gives the entries increasing weights in their current order
+func renumber(items []*MenuItem) {
+	for i, item := range items {
+		item.Weight = (i + 1) * 10
+	}
+}
+
+// Move moves item by steps among its siblings, negative steps moving it up
+func (m *Menu) Move(item *MenuItem, steps int) {
+	siblings := m.Siblings(item)
+	from := indexOf(siblings, item)
+	to := from + steps
+	if to < 0 {
+		to = 0
+	}
+	if to > len(siblings)-1 {
+		to = len(siblings) - 1
+	}
+	if from < 0 || to == from {
+		return
+	}
+
+	siblings = append(siblings[:from], siblings[from+1:]...)
+	siblings = append(siblings[:to], append([]*MenuItem{item}, siblings[to:]...)...)
+	m.setSiblings(item.parent, siblings)
+}
+
+// Indent nests item under the sibling above it
+func (m *Menu) Indent(item *MenuItem) {
+	siblings := m.Siblings(item)
+	i := indexOf(siblings, item)
+	if i <= 0 {
+		return
+	}
+	m.SetParent(item, siblings[i-1])
+}
+
+// Outdent moves item out of its parent, placing it right after it
+func (m *Menu) Outdent(item *MenuItem) {
+	parent := item.parent
+	if parent == nil {
+		return
+	}
+	m.SetParent(item, parent.parent)
+
+	// SetParent appends, move it next to its old parent
+	siblings := m.Siblings(item)
+	m.Move(item, indexOf(siblings, parent)+1-indexOf(siblings, item))
+}
+
+// SetParent nests item at the end of parent's children, or at the top level
+// when parent is nil
+func (m *Menu) SetParent(item, parent *MenuItem) {
+	if parent == item || (parent != nil && isBelow(parent, item)) {
+		return
+	}
+	siblings := m.Siblings(item)
+	if i := indexOf(siblings, item); i >= 0 {
+		m.setSiblings(item.parent, append(siblings[:i], siblings[i+1:]...))
+	}
+
+	item.parent = parent
+	item.Parent = ""
+	if parent != nil {
+		item.Parent = parent.ID()
+	}
+	m.setSiblings(parent, append(m.Siblings(item), item))
+}
+
+// Rename changes the name of an entry, keeping its children attached
+func (m *Menu) Rename(item *MenuItem, name string) {
+	oldID := item.ID()
+	item.Name = name
+	if item.ID() != oldID {
+		for _, child := range item.Children {
+			child.Parent = item.ID()
+		}
+	}
+}
+
+// AddLink adds an entry for a URL to the config, at the end of the menu
+func (m *Menu) AddLink(name, url string) *MenuItem {
+	item := &MenuItem{MenuEntry: MenuEntry{Name: name, URL: url}}
+	m.lists[m.listKey] = append(m.lists[m.listKey], item)
+	m.setSiblings(nil, append(m.Items, item))
+	return item
+}
+
+// AddPage adds an entry for a page to its front matter, at the end of the
+// menu
+func (m *Menu) AddPage(p *Page) *MenuItem {
+	item := &MenuItem{Page: p}
+	m.pages = append(m.pages, item)
+	m.setSiblings(nil, append(m.Items, item))
+	return item
+}
+
+// Remove removes item from the menu. Its children move up a level.
+func (m *Menu) Remove(item *MenuItem) {
+	for _, child := range append([]*MenuItem{}, item.Children...) {
+		m.SetParent(child, item.parent)
+	}
+
+	siblings := m.Siblings(item)
+	if i := indexOf(siblings, item); i >= 0 {
+		m.setSiblings(item.parent, append(siblings[:i], siblings[i+1:]...))
+	}
+
+	if item.Page != nil {
+		m.pages = removeItem(m.pages, item)
+		if item.raw != nil {
+			m.removed = append(m.removed, item)
+		}
+		return
+	}
+	for key, list := range m.lists {
+		m.lists[key] = removeItem(list, item)
+	}
+}
+
+// Save writes the entries back where they come from: config entries to
+// the config file holding the menu, page entries to the page front matter.
+func (m *Menu) Save() error {
+	changes := make(map[string]interface{})
+	for key, items := range m.lists {
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			list[i] = item.entryMap(false)
+		}
+		if !reflect.DeepEqual(list, m.origs[key]) {
+			changes[key] = list
+		}
+	}
+
+	for _, item := range m.removed {
+		if err := UpdatePage(item.Page.Path, func(meta map[string]interface{}) error {
+			setPageMenu(meta, m.Name, nil)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("%s: %w", item.Page.Rel, err)
+		}
+	}
+	m.removed = nil
+
+	for _, item := range m.pages {
+		entry := item.entryMap(true)
+		if item.raw != nil && reflect.DeepEqual(entry, item.raw) {
+			continue
+		}
+		if err := UpdatePage(item.Page.Path, func(meta map[string]interface{}) error {
+			setPageMenu(meta, m.Name, entry)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("%s: %w", item.Page.Rel, err)
+		}
+		item.raw = entry
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return m.site.Save(changes)
+}
+
+// entryMap returns the entry as written in the config or front matter,
+// keeping the fields it doesn't know about
+func (i *MenuItem) entryMap(page bool) map[string]interface{} {
+	out := make(map[string]interface{}, len(i.raw))
+	for k, v := range i.raw {
+		out[k] = v
+	}
+
+	set := func(k string, v interface{}, empty bool) {
+		_, key := lookup(out, k)
+		if key == "" {
+			key = k
+		}
+		if empty {
+			delete(out, key)
+			return
+		}
+		// Keep the type it was written with when the value didn't change
+		if old, ok := out[key]; ok && fmt.Sprint(old) == fmt.Sprint(v) {
+			return
+		}
+		out[key] = v
+	}
+
+	name := i.Name
+	if page && i.Page != nil && name == i.Page.LinkTitle() {
+		name = "" // the default
+	}
+	set("name", name, name == "")
+	set("identifier", i.Identifier, i.Identifier == "")
+	set("parent", i.Parent, i.Parent == "")
+	set("weight", i.Weight, i.Weight == 0)
+	if !page {
+		set("url", i.URL, i.URL == "")
+		set("pageRef", i.PageRef, i.PageRef == "")
+	}
+	return out
+}
+
+// setPageMenu sets the entry of a page in a menu, or removes it when entry
+// is nil. Entries without fields are written as plain menu names.
+func setPageMenu(meta map[string]interface{}, name string, entry map[string]interface{}) {
+	v, key := lookup(meta, "menus")
+	if key == "" {
+		v, key = lookup(meta, "menu")
+	}
+	if key == "" {
+		key = "menu"
+	}
+
+	menus, isMap := asMap(v)
+	if !isMap {
+		names := getStrings(map[string]interface{}{"menu": v}, "menu")
+		names = removeName(names, name)
+		if entry != nil && len(entry) == 0 {
+			names = append(names, name)
+		}
+
+		if entry == nil || len(entry) == 0 {
+			switch {
+			case len(names) == 0:
+				delete(meta, key)
+			case len(names) == 1:
+				if _, wasString := v.(string); wasString || v == nil {
+					meta[key] = names[0]
+					break
+				}
+				fallthrough
+			default:
+				list := make([]interface{}, len(names))
+				for i, n := range names {
+					list[i] = n
+				}
+				meta[key] = list
+			}
+			return
+		}
+
+		menus = make(map[string]interface{}, len(names)+1)
+		for _, n := range names {
+			menus[n] = map[string]interface{}{}
+		}
+	}
+
+	if _, existing := lookup(menus, name); existing != "" {
+		delete(menus, existing)
+	}
+	if entry != nil {
+		menus[name] = entry
+	}
+	if len(menus) == 0 {
+		delete(meta, key)
+		return
+	}
+	meta[key] = menus
+}
+
+func removeName(names []string, name string) []string {
+	out := names[:0]
+	for _, n := range names {
+		if !strings.EqualFold(n, name) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func indexOf(items []*MenuItem, item *MenuItem) int {
+	for i, it := range items {
+		if it == item {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeItem(items []*MenuItem, item *MenuItem) []*MenuItem {
+	out := make([]*MenuItem, 0, len(items))
+	for _, it := range items {
+		if it != item {
+			out = append(out, it)
+		}
+	}
+	return out
+}
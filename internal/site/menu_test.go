@@ -0,0 +1,179 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var menuSite = map[string]string{
+	"hugo.toml": `[[menus.main]]
+  name = "Home"
+  url = "/"
+  weight = 1
+[[menus.main]]
+  name = "Docs"
+  identifier = "docs"
+  url = "/docs/"
+  weight = 3
+`,
+	"content/about.md":       "---\ntitle: About\nmenu: main\n---\n",
+	"content/news.md":        "---\ntitle: News\nmenu:\n  main:\n    weight: 2\n---\n",
+	"content/posts/hello.md": "---\ntitle: Hello\nmenu:\n  main:\n    parent: docs\n    weight: 1\n---\n",
+	"content/posts/other.md": "---\ntitle: Other\nmenu: footer\n---\n",
+}
+
+// outline writes a menu tree as "A, B(C, D)"
+func outline(items []*MenuItem) string {
+	labels := make([]string, len(items))
+	for i, item := range items {
+		labels[i] = item.Label()
+		if len(item.Children) > 0 {
+			labels[i] += "(" + outline(item.Children) + ")"
+		}
+	}
+	return strings.Join(labels, ", ")
+}
+
+func findItem(items []*MenuItem, label string) *MenuItem {
+	for _, item := range items {
+		if item.Label() == label {
+			return item
+		}
+		if found := findItem(item.Children, label); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func loadMenu(t *testing.T, dir string) *Menu {
+	t.Helper()
+	c, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages, err := c.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.LoadMenu("main", "en", pages)
+}
+
+func TestMenu(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(m *Menu)
+		want string
+	}{
+		{"load", func(m *Menu) {}, "Home, News, Docs(Hello), About"},
+		{"move up", func(m *Menu) { m.Move(findItem(m.Items, "About"), -1) }, "Home, News, About, Docs(Hello)"},
+		{"move past the top", func(m *Menu) { m.Move(findItem(m.Items, "Docs"), -5) }, "Docs(Hello), Home, News, About"},
+		{"indent", func(m *Menu) { m.Indent(findItem(m.Items, "About")) }, "Home, News, Docs(Hello, About)"},
+		{"indent the first", func(m *Menu) { m.Indent(findItem(m.Items, "Home")) }, "Home, News, Docs(Hello), About"},
+		{"outdent", func(m *Menu) { m.Outdent(findItem(m.Items, "Hello")) }, "Home, News, Docs, Hello, About"},
+		{"remove a parent", func(m *Menu) { m.Remove(findItem(m.Items, "Docs")) }, "Home, News, About, Hello"},
+		{"add a link", func(m *Menu) { m.AddLink("Blog", "/blog/") }, "Home, News, Docs(Hello), About, Blog"},
+		{"rename a parent", func(m *Menu) { m.Rename(findItem(m.Items, "Docs"), "Guides") }, "Home, News, Guides(Hello), About"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeSite(t, menuSite)
+			m := loadMenu(t, dir)
+			tt.edit(m)
+			if got := outline(m.Items); got != tt.want {
+				t.Fatalf("menu = %s, want %s", got, tt.want)
+			}
+
+			if err := m.Save(); err != nil {
+				t.Fatal(err)
+			}
+			if got := outline(loadMenu(t, dir).Items); got != tt.want {
+				t.Errorf("saved menu = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMenuSave(t *testing.T) {
+	dir := writeSite(t, menuSite)
+	m := loadMenu(t, dir)
+	m.Indent(findItem(m.Items, "About"))
+	m.Remove(findItem(m.Items, "News"))
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file, want string
+	}{
+		{"content/about.md", "---\ntitle: About\nmenu:\n  main:\n    parent: docs\n    weight: 20\n---\n"},
+		{"content/news.md", "---\ntitle: News\n---\n"},
+		{"content/posts/hello.md", "---\ntitle: Hello\nmenu:\n  main:\n    parent: docs\n    weight: 10\n---\n"},
+		{"content/posts/other.md", "---\ntitle: Other\nmenu: footer\n---\n"},
+		{"hugo.toml", `[[menus.main]]
+  name = "Home"
+  url = "/"
+  weight = 10
+[[menus.main]]
+  name = "Docs"
+  identifier = "docs"
+  url = "/docs/"
+  weight = 20
+`},
+	}
+	for _, tt := range tests {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(tt.file)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestLoadMenuLanguage(t *testing.T) {
+	dir := writeSite(t, map[string]string{
+		"hugo.toml": `defaultContentLanguage = "en"
+[[menus.main]]
+  name = "Shared"
+  url = "/"
+[languages.en]
+  weight = 1
+[languages.ar]
+  weight = 2
+  [[languages.ar.menus.main]]
+    name = "الرئيسية"
+    url = "/ar/"
+    weight = 1
+`,
+		"content/about.md":    "---\ntitle: About\nmenu: main\n---\n",
+		"content/about.ar.md": "---\ntitle: عن\nmenu:\n  main:\n    weight: 2\n---\n",
+	})
+	c, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages, err := c.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		lang, want string
+	}{
+		{"en", "About, Shared"},
+		{"ar", "الرئيسية, عن, Shared"},
+	}
+	for _, tt := range tests {
+		if got := outline(c.LoadMenu("main", tt.lang, pages).Items); got != tt.want {
+			t.Errorf("%s menu = %s, want %s", tt.lang, got, tt.want)
+		}
+	}
+	if got, want := strings.Join(c.MenuNames(pages), ","), "main"; got != want {
+		t.Errorf("MenuNames = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,184 @@
+package site
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+)
+
+// Permalink returns the URL Hugo publishes p at: baseURL, then the
+// language prefix, then the path the page's url, the permalinks of its
+// section or its file give it.
+func (c *SiteConfig) Permalink(p *Page) string {
+	return strings.TrimRight(c.BaseURL, "/") + c.RelPermalink(p)
+}
+
+// RelPermalink returns the path of the URL of p, e.g. /en/posts/hello/
+func (c *SiteConfig) RelPermalink(p *Page) string {
+	prefix := ""
+	if p.Lang != c.DefaultContentLanguage || c.DefaultContentLanguageInSubdir {
+		prefix = "/" + p.Lang
+	}
+
+	// url is relative to baseURL with a leading slash, to the language
+	// root without
+	if u := getString(p.Meta, "url"); u != "" {
+		if strings.HasPrefix(u, "/") {
+			return u
+		}
+		return prefix + "/" + u
+	}
+
+	var rel string
+	if pattern, ok := c.Permalinks[p.Section()]; ok && p.Kind() == "page" {
+		rel = c.expandPermalink(pattern, p)
+	} else {
+		rel = c.filePath(p)
+	}
+	if !getBool(c.Raw, "disablePathToLower") {
+		rel = strings.ToLower(rel)
+	}
+	return prefix + rel
+}
+
+// filePath returns the path Hugo derives from the content file: its
+// directory and its slug or name, in a directory of its own unless the
+// site has uglyURLs
+func (c *SiteConfig) filePath(p *Page) string {
+	dir := path.Dir(p.keyPath())
+	if p.Kind() != "page" {
+		if dir == "." {
+			return "/"
+		}
+		return "/" + dir + "/"
+	}
+
+	name := contentBaseName(p)
+	if slug := getString(p.Meta, "slug"); slug != "" {
+		name = slug
+	}
+	if isIndex, _ := cms.IsBundleIndex(p.Path); isIndex {
+		dir = path.Dir(dir)
+	}
+	rel := "/" + path.Join(dir, name)
+	if c.UglyURLs {
+		return rel + ".html"
+	}
+	return rel + "/"
+}
+
+// contentBaseName returns the name of the file of p without extension and
+// language, or the name of its directory for a bundle
+func contentBaseName(p *Page) string {
+	key := p.keyPath()
+	if isIndex, _ := cms.IsBundleIndex(p.Path); isIndex {
+		return path.Base(path.Dir(key))
+	}
+	return strings.TrimSuffix(path.Base(key), path.Ext(key))
+}
+
+// expandPermalink fills in the tokens of a permalinks pattern such as
+// /:year/:month/:slug/
+func (c *SiteConfig) expandPermalink(pattern string, p *Page) string {
+	date, _ := p.Time("date")
+	slug := getString(p.Meta, "slug")
+
+	sections := path.Dir(p.keyPath())
+	if isIndex, _ := cms.IsBundleIndex(p.Path); isIndex {
+		sections = path.Dir(sections)
+	}
+
+	tokens := map[string]string{
+		"year":            strconv.Itoa(date.Year()),
+		"month":           date.Format("01"),
+		"monthname":       strings.ToLower(date.Month().String()),
+		"day":             date.Format("02"),
+		"weekday":         strconv.Itoa(int(date.Weekday())),
+		"weekdayname":     strings.ToLower(date.Weekday().String()),
+		"yearday":         strconv.Itoa(date.YearDay()),
+		"section":         p.Section(),
+		"sections":        strings.TrimPrefix(sections, "."),
+		"title":           Urlize(p.Title()),
+		"slug":            slug,
+		"filename":        contentBaseName(p),
+		"contentbasename": contentBaseName(p),
+	}
+	if slug == "" {
+		tokens["slug"] = tokens["title"]
+	}
+	tokens["slugorfilename"] = slug
+	tokens["slugorcontentbasename"] = slug
+	if slug == "" {
+		tokens["slugorfilename"] = tokens["filename"]
+		tokens["slugorcontentbasename"] = tokens["filename"]
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != ':' {
+			b.WriteByte(pattern[i])
+			continue
+		}
+		j := i + 1
+		for j < len(pattern) && (pattern[j] >= 'a' && pattern[j] <= 'z') {
+			j++
+		}
+		if val, ok := tokens[pattern[i+1:j]]; ok {
+			b.WriteString(val)
+			i = j - 1
+			continue
+		}
+		b.WriteByte(':')
+	}
+
+	rel := path.Clean("/" + b.String())
+	if c.UglyURLs {
+		return rel + ".html"
+	}
+	if rel == "/" {
+		return rel
+	}
+	return rel + "/"
+}
+
+// Urlize turns text into a path element the way Hugo's urlize does:
+// spaces become dashes and punctuation is dropped, letters of any script
+// are kept
+func Urlize(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.TrimSpace(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || r == '_' || r == '.':
+			b.WriteRune(r)
+			dash = false
+		case unicode.IsSpace(r) || r == '-' || r == '/':
+			if !dash && b.Len() > 0 {
+				b.WriteByte('-')
+				dash = true
+			}
+		}
+	}
+	return strings.ToLower(strings.TrimRight(b.String(), "-"))
+}
+
+// Time returns a date of the front matter, such as date or publishDate
+func (p *Page) Time(key string) (time.Time, bool) {
+	v, found := lookup(p.Meta, key)
+	if found == "" {
+		return time.Time{}, false
+	}
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		if parsed, err := cms.ParseDate(t, nil); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
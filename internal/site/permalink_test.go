@@ -0,0 +1,120 @@
+package site
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRelPermalink(t *testing.T) {
+	const multilingual = `defaultContentLanguage = "en"
+[languages.en]
+  weight = 1
+[languages.ar]
+  weight = 2
+`
+
+	tests := []struct {
+		name   string
+		config string
+		file   string // Relative to the content directory
+		page   string
+		want   string
+	}{
+		{"file name", "", "posts/hello.md", "---\ntitle: Hello\n---\n", "/posts/hello/"},
+		{"slug", "", "posts/hello.md", "---\nslug: Custom\n---\n", "/posts/custom/"},
+		{"bundle", "", "posts/trip/index.md", "", "/posts/trip/"},
+		{"section", "", "posts/_index.md", "", "/posts/"},
+		{"home", "", "_index.md", "", "/"},
+		{"url", "", "posts/hello.md", "---\nurl: /elsewhere/\n---\n", "/elsewhere/"},
+		{"ugly URLs", "uglyURLs = true", "posts/hello.md", "", "/posts/hello.html"},
+		{"upper case", "", "posts/Hello.md", "", "/posts/hello/"},
+		{"disablePathToLower", "disablePathToLower = true", "posts/Hello.md", "", "/posts/Hello/"},
+		{
+			name:   "date and slug",
+			config: "[permalinks]\n  posts = \"/:year/:month/:day/:slug/\"",
+			file:   "posts/hello.md",
+			page:   "---\ndate: 2024-05-01\nslug: custom\n---\n",
+			want:   "/2024/05/01/custom/",
+		},
+		{
+			name:   "slug from the title",
+			config: "[permalinks]\n  posts = \"/:section/:slug/\"",
+			file:   "posts/hello.md",
+			page:   "---\ntitle: Hello, World!\n---\n",
+			want:   "/posts/hello-world/",
+		},
+		{
+			name:   "sections",
+			config: "[permalinks]\n  docs = \"/:sections/:slugorfilename/\"",
+			file:   "docs/guide/install.md",
+			page:   "",
+			want:   "/docs/guide/install/",
+		},
+		{
+			name:   "sections of a bundle",
+			config: "[permalinks]\n  docs = \"/:sections/:contentbasename/\"",
+			file:   "docs/guide/setup/index.md",
+			page:   "",
+			want:   "/docs/guide/setup/",
+		},
+		{
+			name:   "permalinks.page",
+			config: "[permalinks.page]\n  posts = \"/p/:filename/\"\n[permalinks.section]\n  posts = \"/articles/\"",
+			file:   "posts/hello.md",
+			page:   "",
+			want:   "/p/hello/",
+		},
+		{
+			name:   "sections keep their path",
+			config: "[permalinks]\n  posts = \"/:year/:slug/\"",
+			file:   "posts/_index.md",
+			page:   "",
+			want:   "/posts/",
+		},
+		{"default language", multilingual, "posts/hello.md", "", "/posts/hello/"},
+		{"other language", multilingual, "posts/hello.ar.md", "", "/ar/posts/hello/"},
+		{"relative url", multilingual, "posts/hello.ar.md", "---\nurl: elsewhere/\n---\n", "/ar/elsewhere/"},
+		{"default language in a subdir", "defaultContentLanguageInSubdir = true\n" + multilingual, "posts/hello.md", "", "/en/posts/hello/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeSite(t, map[string]string{
+				"hugo.toml":          tt.config,
+				"content/" + tt.file: tt.page,
+			})
+			c, err := Load(dir, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			pages, err := c.Pages()
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := FindPage(pages, filepath.Join(dir, "content", filepath.FromSlash(tt.file)))
+			if p == nil {
+				t.Fatalf("%s not found", tt.file)
+			}
+			if got := c.RelPermalink(p); got != tt.want {
+				t.Errorf("RelPermalink = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUrlize(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"  Go  Tips ", "go-tips"},
+		{"a/b - c", "a-b-c"},
+		{"مرحبا بالعالم", "مرحبا-بالعالم"},
+		{"v1.2_beta", "v1.2_beta"},
+	}
+	for _, tt := range tests {
+		if got := Urlize(tt.text); got != tt.want {
+			t.Errorf("Urlize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package site
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// RelatedConfig is the related section of the configuration, telling how
+// Hugo finds the related content of a page
+type RelatedConfig struct {
+	IncludeNewer bool // Pages published after the page can be related
+	Threshold    int  // From 0 to 100, how related pages must be
+	ToLower      bool
+	Indices      []RelatedIndex
+}
+
+// RelatedIndex is a front matter parameter pages are related by
+type RelatedIndex struct {
+	Name                 string
+	Weight               int
+	Type                 string // basic, or fragments for the headings of the content
+	Pattern              string // Layout of dates, e.g. 2006 to relate pages of the same year
+	ToLower              bool
+	CardinalityThreshold int // Keywords of more than this percent of pages are ignored
+}
+
+// RelatedPage is a page Hugo lists as related, with its rank
+type RelatedPage struct {
+	Page     *Page
+	Weight   int      // From 0 to 100
+	Keywords []string // The keywords shared, as index: keyword
+
+	sum int // The weights of the keywords added up, to rank by
+}
+
+// KeywordSuggestion is a keyword other pages have that would relate more
+// pages to a page having it too
+type KeywordSuggestion struct {
+	Index   string
+	Keyword string
+	Gain    int  // Pages it would make related
+	InText  bool // Whether the page mentions it
+}
+
+// Related returns the related configuration, with Hugo's defaults: the
+// keywords and date parameters, and tags when the site has them
+func (c *SiteConfig) Related() RelatedConfig {
+	cfg := RelatedConfig{Threshold: 80}
+	m := getMap(c.Raw, "related")
+	if len(m) == 0 {
+		cfg.Indices = []RelatedIndex{
+			{Name: "keywords", Weight: 100, Type: "basic"},
+			{Name: "date", Weight: 10, Type: "basic", Pattern: "2006"},
+		}
+		for _, plural := range c.Taxonomies {
+			if plural == "tags" {
+				cfg.Indices = append(cfg.Indices, RelatedIndex{Name: "tags", Weight: 80, Type: "basic"})
+			}
+		}
+		return cfg
+	}
+
+	cfg.IncludeNewer = getBool(m, "includeNewer")
+	if _, key := lookup(m, "threshold"); key != "" {
+		cfg.Threshold = getInt(m, "threshold")
+	}
+	cfg.ToLower = getBool(m, "toLower")
+	v, _ := lookup(m, "indices")
+	for _, item := range asList(v) {
+		im, ok := asMap(item)
+		if !ok || getString(im, "name") == "" {
+			continue
+		}
+		index := RelatedIndex{
+			Name:                 getString(im, "name"),
+			Weight:               getInt(im, "weight"),
+			Type:                 getString(im, "type"),
+			Pattern:              getString(im, "pattern"),
+			ToLower:              getBool(im, "toLower"),
+			CardinalityThreshold: getInt(im, "cardinalityThreshold"),
+		}
+		if index.Type == "" {
+			index.Type = "basic"
+		}
+		cfg.Indices = append(cfg.Indices, index)
+	}
+	return cfg
+}
+
+// relatedIndex is the inverted index Hugo searches related content in:
+// for each parameter, the pages having each keyword
+type relatedIndex struct {
+	cfg       RelatedConfig
+	keywords  map[string]map[string][]*Page // index -> keyword -> pages
+	minWeight int
+	maxWeight int
+}
+
+// newRelatedIndex indexes the regular pages of the site Hugo builds in the
+// language of p, other than p
+func (c *SiteConfig) newRelatedIndex(pages []*Page, p *Page) *relatedIndex {
+	ix := &relatedIndex{
+		cfg:      c.Related(),
+		keywords: make(map[string]map[string][]*Page),
+	}
+	// The scale starts at 0, lower for negative weights
+	for _, index := range ix.cfg.Indices {
+		ix.minWeight = min(ix.minWeight, index.Weight)
+		ix.maxWeight = max(ix.maxWeight, index.Weight)
+	}
+
+	now := time.Now()
+	var candidates []*Page
+	for _, other := range pages {
+		if other.Path != p.Path && other.Lang == p.Lang && other.Kind() == "page" && other.Status(now) == StatusPublished {
+			candidates = append(candidates, other)
+		}
+	}
+
+	for _, index := range ix.cfg.Indices {
+		byKeyword := make(map[string][]*Page)
+		for _, other := range candidates {
+			for _, kw := range c.relatedKeywords(other, index) {
+				byKeyword[kw] = append(byKeyword[kw], other)
+			}
+		}
+		if index.CardinalityThreshold > 0 && len(candidates) > 0 {
+			for kw, docs := range byKeyword {
+				if len(docs)*100/len(candidates) > index.CardinalityThreshold {
+					delete(byKeyword, kw)
+				}
+			}
+		}
+		ix.keywords[index.Name] = byKeyword
+	}
+	return ix
+}
+
+// relatedKeywords returns the keywords of p for an index: the value of
+// the parameter, falling back on the site params as .Param does, the date
+// in the layout of the index, or the IDs of the headings for fragments
+func (c *SiteConfig) relatedKeywords(p *Page, index RelatedIndex) []string {
+	var values []string
+	switch {
+	case index.Type == "fragments":
+		values = headingIDs(p.Body)
+	case strings.EqualFold(index.Name, "date"):
+		if t, ok := p.Date(); ok {
+			pattern := index.Pattern
+			if pattern == "" {
+				pattern = time.RFC3339
+			}
+			values = []string{t.Format(pattern)}
+		}
+	default:
+		v, key := lookup(p.Meta, index.Name)
+		if key == "" {
+			v, key = lookup(c.Params, index.Name)
+		}
+		if key != "" {
+			values = keywordStrings(v, index.Pattern)
+		}
+	}
+
+	if index.ToLower || c.Related().ToLower {
+		for i, v := range values {
+			values[i] = strings.ToLower(v)
+		}
+	}
+	return values
+}
+
+func keywordStrings(v interface{}, pattern string) []string {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case time.Time:
+		if pattern == "" {
+			pattern = time.RFC3339
+		}
+		return []string{v.Format(pattern)}
+	}
+	if list := asList(v); list != nil {
+		var out []string
+		for _, item := range list {
+			out = append(out, keywordStrings(item, pattern)...)
+		}
+		return out
+	}
+	return []string{fmt.Sprint(v)}
+}
+
+var markdownHeading = regexp.MustCompile(`(?m)^#{1,6}[ \t]+(.+?)[ \t#]*(?:\{#([^}]+)\})?[ \t]*$`)
+
+// headingIDs returns the IDs Hugo gives the headings of Markdown: those
+// set with {#id}, else the text in lower case with dashes for spaces
+func headingIDs(body string) []string {
+	var ids []string
+	for _, m := range markdownHeading.FindAllStringSubmatch(body, -1) {
+		if m[2] != "" {
+			ids = append(ids, m[2])
+			continue
+		}
+		var b strings.Builder
+		for _, r := range strings.ToLower(strings.TrimSpace(m[1])) {
+			switch {
+			case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+				b.WriteRune(r)
+			case unicode.IsSpace(r):
+				b.WriteByte('-')
+			}
+		}
+		ids = append(ids, b.String())
+	}
+	return ids
+}
+
+// search ranks the pages sharing keywords with p the way Hugo does: each
+// shared keyword adds the weight of its index, pages whose average weight,
+// scaled from 0 to 100 by the heaviest index, passes a threshold lowered
+// by the number of keywords they share are kept, and the added up weight
+// ranks them
+func (ix *relatedIndex) search(p *Page, keywords map[string][]string) []RelatedPage {
+	upper, hasDate := p.PublishDate()
+	filterDate := !ix.cfg.IncludeNewer && hasDate
+
+	type rank struct {
+		weight   int
+		matches  int
+		keywords []string
+	}
+	ranks := make(map[*Page]*rank)
+	for _, index := range ix.cfg.Indices {
+		for _, kw := range keywords[index.Name] {
+			for _, other := range ix.keywords[index.Name][kw] {
+				if filterDate {
+					if t, ok := other.PublishDate(); ok && t.After(upper) {
+						continue
+					}
+				}
+				r, ok := ranks[other]
+				if !ok {
+					r = &rank{}
+					ranks[other] = r
+				}
+				r.weight += index.Weight
+				r.matches++
+				r.keywords = append(r.keywords, index.Name+": "+kw)
+			}
+		}
+	}
+
+	var out []RelatedPage
+	for other, r := range ranks {
+		weight := 100
+		if ix.maxWeight > ix.minWeight {
+			avg := r.weight / r.matches
+			weight = int(math.Floor(float64(avg-ix.minWeight)/float64(ix.maxWeight-ix.minWeight)*100 + 0.5))
+		}
+		if weight >= ix.cfg.Threshold/r.matches {
+			out = append(out, RelatedPage{Page: other, Weight: weight, Keywords: r.keywords, sum: r.weight})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].sum != out[j].sum {
+			return out[i].sum > out[j].sum
+		}
+		ti, _ := out[i].Page.PublishDate()
+		tj, _ := out[j].Page.PublishDate()
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return out[i].Page.Title() < out[j].Page.Title()
+	})
+	return out
+}
+
+// RelatedPages returns the pages Hugo lists as .Related of p, most related
+// first
+func (c *SiteConfig) RelatedPages(pages []*Page, p *Page) []RelatedPage {
+	ix := c.newRelatedIndex(pages, p)
+	return ix.search(p, c.queryKeywords(ix, p))
+}
+
+func (c *SiteConfig) queryKeywords(ix *relatedIndex, p *Page) map[string][]string {
+	keywords := make(map[string][]string)
+	for _, index := range ix.cfg.Indices {
+		keywords[index.Name] = c.relatedKeywords(p, index)
+	}
+	return keywords
+}
+
+// SuggestKeywords finds the keywords of the front matter indices, such as
+// tags, that other pages have and p hasn't, which would make more pages
+// related to it. Keywords p mentions in its title or text come first among
+// those relating as many pages.
+func (c *SiteConfig) SuggestKeywords(pages []*Page, p *Page, limit int) []KeywordSuggestion {
+	ix := c.newRelatedIndex(pages, p)
+	keywords := c.queryKeywords(ix, p)
+	related := make(map[*Page]bool)
+	for _, r := range ix.search(p, keywords) {
+		related[r.Page] = true
+	}
+	text := strings.ToLower(p.Title() + "\n" + p.Body)
+
+	var out []KeywordSuggestion
+	for _, index := range ix.cfg.Indices {
+		if index.Type == "fragments" || strings.EqualFold(index.Name, "date") {
+			continue
+		}
+		has := make(map[string]bool)
+		for _, kw := range keywords[index.Name] {
+			has[strings.ToLower(kw)] = true
+		}
+		for kw := range ix.keywords[index.Name] {
+			if has[strings.ToLower(kw)] {
+				continue
+			}
+
+			with := make(map[string][]string, len(keywords))
+			for name, kws := range keywords {
+				with[name] = kws
+			}
+			with[index.Name] = append(append([]string(nil), keywords[index.Name]...), kw)
+			gain := 0
+			for _, r := range ix.search(p, with) {
+				if !related[r.Page] {
+					gain++
+				}
+			}
+			if gain > 0 {
+				out = append(out, KeywordSuggestion{
+					Index:   index.Name,
+					Keyword: kw,
+					Gain:    gain,
+					InText:  strings.Contains(text, strings.ToLower(kw)),
+				})
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Gain != out[j].Gain {
+			return out[i].Gain > out[j].Gain
+		}
+		if out[i].InText != out[j].InText {
+			return out[i].InText
+		}
+		return out[i].Keyword < out[j].Keyword
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
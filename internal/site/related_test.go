@@ -0,0 +1,114 @@
+package site
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var relatedPages = map[string]string{
+	"content/posts/p.md":      "---\ntitle: P\ntags: [go, web]\nkeywords: [hugo]\ndate: 2024-03-01\n---\nUnlike Rust, Go is simple.\n",
+	"content/posts/a.md":      "---\ntitle: A\ntags: [go, web]\ndate: 2023-05-01\n---\n",
+	"content/posts/b.md":      "---\ntitle: B\nkeywords: [hugo]\ndate: 2024-01-01\n---\n",
+	"content/posts/c.md":      "---\ntitle: C\ntags: [rust]\ndate: 2024-02-01\n---\n",
+	"content/posts/d.md":      "---\ntitle: D\ntags: [Go]\ndate: 2022-01-01\n---\n",
+	"content/posts/e.md":      "---\ntitle: E\ntags: [go, web]\ndate: 2025-01-01\n---\n",
+	"content/posts/draft.md":  "---\ntitle: Draft\ntags: [go, web]\ndraft: true\n---\n",
+	"content/posts/_index.md": "---\ntitle: Posts\ntags: [go, web]\n---\n",
+}
+
+const relatedIndices = `
+[[related.indices]]
+  name = "keywords"
+  weight = 100
+[[related.indices]]
+  name = "date"
+  weight = 10
+  pattern = "2006"
+[[related.indices]]
+  name = "tags"
+  weight = 80
+`
+
+// loadRelated loads a site with the related pages and the given config,
+// returning its pages and the page P they are related to
+func loadRelated(t *testing.T, config string) (*SiteConfig, []*Page, *Page) {
+	t.Helper()
+	files := map[string]string{"hugo.toml": config}
+	for name, content := range relatedPages {
+		files[name] = content
+	}
+	dir := writeSite(t, files)
+	c, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages, err := c.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, pages, FindPage(pages, filepath.Join(dir, "content", "posts", "p.md"))
+}
+
+func TestRelatedPages(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string // Title and weight of each related page
+	}{
+		{"defaults", "", "A 80, B 55"},
+		{"case of keywords", "[related]\n  toLower = true" + relatedIndices, "A 80, B 55, D 80"},
+		{"newer pages", "[related]\n  includeNewer = true" + relatedIndices, "E 80, A 80, B 55"},
+		{"threshold", "[related]\n  threshold = 0\n  toLower = true" + relatedIndices, "A 80, B 55, D 80, C 10"},
+		{
+			name:   "cardinality threshold",
+			config: "[related]\n  toLower = true" + strings.Replace(relatedIndices, "weight = 80", "weight = 80\n  cardinalityThreshold = 50", 1),
+			want:   "B 55, A 80",
+		},
+		{
+			name:   "single index",
+			config: "[related]\n  threshold = 50\n[[related.indices]]\n  name = \"tags\"\n  weight = 1",
+			want:   "A 100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, pages, p := loadRelated(t, tt.config)
+			var got []string
+			for _, r := range c.RelatedPages(pages, p) {
+				got = append(got, fmt.Sprintf("%s %d", r.Page.Title(), r.Weight))
+			}
+			if strings.Join(got, ", ") != tt.want {
+				t.Errorf("related = %s, want %s", strings.Join(got, ", "), tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestKeywords(t *testing.T) {
+	c, pages, p := loadRelated(t, "")
+	got := c.SuggestKeywords(pages, p, 0)
+	want := []KeywordSuggestion{{Index: "tags", Keyword: "rust", Gain: 1, InText: true}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("suggestions = %+v, want %+v", got, want)
+	}
+}
+
+func TestHeadingIDs(t *testing.T) {
+	tests := []struct {
+		body, want string
+	}{
+		{"## Getting Started\n", "getting-started"},
+		{"# Setup {#install}\n", "install"},
+		{"### What's new? ###\n", "whats-new"},
+		{"Text\n#hashtag\n####### Too deep\n", ""},
+		{"## One\n\n## Two\n", "one two"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(headingIDs(tt.body), " "); got != tt.want {
+			t.Errorf("headingIDs(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
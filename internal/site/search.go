@@ -0,0 +1,632 @@
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+)
+
+// searchIndexVersion changes when the documents are read differently, so
+// older indexes are rebuilt
+const searchIndexVersion = 2
+
+// SearchIndex holds the text of every page of a site for full-text search.
+// It is saved between sessions with the words of each page, and updated
+// from the files that changed since, so searching waits neither for the
+// content to be read nor for it to be split into words. Changes are saved
+// on their own, after the whole index, until they are worth writing it
+// again.
+type SearchIndex struct {
+	Version int
+	Dir     string                // The site root
+	Docs    map[string]*SearchDoc // By path of the file
+
+	postings map[string]map[string]termCount // token -> path -> counts
+	tokens   []string                        // Tokens of postings, sorted
+	sorted   bool                            // Whether tokens is up to date
+	changes  int                             // Changes saved since the whole index
+}
+
+// SearchDoc is a page as the search index keeps it
+type SearchDoc struct {
+	Path    string
+	Rel     string
+	Lang    string
+	Section string
+	ModTime time.Time
+	Size    int64
+
+	Title       string
+	Fields      string              // Text of the other front matter values
+	Body        string              // Plain text of the content
+	Terms       map[string][]string // Taxonomy -> terms
+	Draft       bool
+	PublishDate time.Time
+	ExpiryDate  time.Time
+
+	Counts map[string]termCount // Token -> where it is, how often
+}
+
+// Status returns the publication status of the page at now, as Status of
+// a page does
+func (d *SearchDoc) Status(now time.Time) string {
+	switch {
+	case d.Draft:
+		return StatusDraft
+	case !d.ExpiryDate.IsZero() && !d.ExpiryDate.After(now):
+		return StatusExpired
+	case d.PublishDate.After(now):
+		return StatusScheduled
+	}
+	return StatusPublished
+}
+
+// termCount is how often a token is in the title, the front matter and
+// the content of a page
+type termCount struct {
+	Title, Fields, Body int
+}
+
+// searchChange is a document of the index as it changed, nil when it was
+// removed
+type searchChange struct {
+	Path string
+	Doc  *SearchDoc
+}
+
+// SearchQuery is what to search for and in which pages. Empty filters
+// match every page.
+type SearchQuery struct {
+	Text     string
+	Section  string
+	Lang     string
+	Status   string // One of the Status constants
+	Taxonomy string // The plural name, e.g. tags
+	Term     string
+}
+
+// SearchResult is a page matching a query, with an extract of its text
+// around the first match
+type SearchResult struct {
+	Doc     *SearchDoc
+	Score   float64
+	Snippet string
+	Matches [][2]int // Byte ranges of the matching words in Snippet
+}
+
+// NewSearchIndex returns an empty index of the site, to Update
+func (c *SiteConfig) NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		Version:  searchIndexVersion,
+		Dir:      c.Dir,
+		Docs:     make(map[string]*SearchDoc),
+		postings: make(map[string]map[string]termCount),
+	}
+}
+
+// ReadSearchIndex reads an index written by Write, then the changes saved
+// after it with WriteChanges, if any. An index of an older version or of
+// another site comes back empty.
+func (c *SiteConfig) ReadSearchIndex(r, changes io.Reader) (*SearchIndex, error) {
+	ix := c.NewSearchIndex()
+	if err := json.NewDecoder(r).Decode(ix); err != nil {
+		return c.NewSearchIndex(), err
+	}
+	if ix.Version != searchIndexVersion || ix.Dir != c.Dir || ix.Docs == nil {
+		return c.NewSearchIndex(), nil
+	}
+	if changes != nil {
+		dec := json.NewDecoder(changes)
+		for {
+			var change searchChange
+			if err := dec.Decode(&change); err != nil {
+				break // The end, or a change cut short
+			}
+			if change.Doc == nil {
+				delete(ix.Docs, change.Path)
+			} else {
+				ix.Docs[change.Path] = change.Doc
+			}
+			ix.changes++
+		}
+	}
+	ix.build()
+	return ix, nil
+}
+
+// Write saves the whole index, to be read back with ReadSearchIndex. The
+// changes saved before are part of it.
+func (ix *SearchIndex) Write(w io.Writer) error {
+	ix.changes = 0
+	return json.NewEncoder(w).Encode(ix)
+}
+
+// WriteChanges saves the documents of paths as they are now, removed or
+// not, to be read back after the index last written
+func (ix *SearchIndex) WriteChanges(w io.Writer, paths []string) error {
+	enc := json.NewEncoder(w)
+	for _, path := range paths {
+		if err := enc.Encode(searchChange{Path: path, Doc: ix.Docs[path]}); err != nil {
+			return err
+		}
+		ix.changes++
+	}
+	return nil
+}
+
+// Compact reports whether so many changes were saved since the whole index
+// that writing it again is worth it
+func (ix *SearchIndex) Compact() bool {
+	return ix.changes > 16+len(ix.Docs)/4
+}
+
+// Update reads the content files that were added or changed since the
+// index was last updated and forgets those removed. It returns the paths
+// of the pages that changed.
+func (ix *SearchIndex) Update(c *SiteConfig) ([]string, error) {
+	unchanged := make(map[string]bool)
+	pages, err := c.walkPages(func(path string, d fs.DirEntry) (*Page, error) {
+		info, err := d.Info()
+		if err != nil {
+			return nil, err
+		}
+		if doc, ok := ix.Docs[path]; ok && doc.ModTime.Equal(info.ModTime()) && doc.Size == info.Size() {
+			unchanged[path] = true
+			return &Page{Path: path}, nil
+		}
+		return ReadPage(path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ix.postings == nil {
+		ix.build()
+	}
+
+	var changed []string
+	found := make(map[string]bool, len(pages))
+	for _, p := range pages {
+		found[p.Path] = true
+		if unchanged[p.Path] {
+			continue
+		}
+		doc, err := c.searchDoc(p)
+		if err != nil {
+			continue
+		}
+		ix.remove(p.Path)
+		ix.add(doc)
+		changed = append(changed, p.Path)
+	}
+	for path := range ix.Docs {
+		if !found[path] {
+			ix.remove(path)
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// searchDoc reads what the index keeps of a page
+func (c *SiteConfig) searchDoc(p *Page) (*SearchDoc, error) {
+	info, err := os.Stat(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	doc := &SearchDoc{
+		Path:    p.Path,
+		Rel:     p.Rel,
+		Lang:    p.Lang,
+		Section: p.Section(),
+		ModTime: info.ModTime(),
+		Size:    info.Size(),
+		Title:   p.Title(),
+		Body:    cms.PlainText(p.Body),
+		Terms:   make(map[string][]string),
+		Draft:   getBool(p.Meta, "draft"),
+	}
+	doc.PublishDate, _ = p.PublishDate()
+	doc.ExpiryDate, _ = p.ExpiryDate()
+
+	skip := map[string]bool{"title": true, "draft": true}
+	for _, plural := range c.Taxonomies {
+		if terms := pageTerms(p.Meta, plural); len(terms) > 0 {
+			doc.Terms[plural] = terms
+		}
+		skip[strings.ToLower(plural)] = true
+	}
+	var fields []string
+	for _, k := range sortedKeys(p.Meta) {
+		if !skip[strings.ToLower(k)] {
+			fields = appendText(fields, p.Meta[k])
+		}
+	}
+	doc.Fields = strings.Join(fields, "\n")
+
+	doc.Counts = make(map[string]termCount)
+	count := func(text string, add func(*termCount)) {
+		searchWords(text, func(_, _ int, token string) {
+			tc := doc.Counts[token]
+			add(&tc)
+			doc.Counts[token] = tc
+		})
+	}
+	count(doc.Title, func(tc *termCount) { tc.Title++ })
+	count(doc.Fields, func(tc *termCount) { tc.Fields++ })
+	for _, terms := range doc.Terms {
+		count(strings.Join(terms, " "), func(tc *termCount) { tc.Fields++ })
+	}
+	count(doc.Body, func(tc *termCount) { tc.Body++ })
+	return doc, nil
+}
+
+// appendText adds the strings found in a front matter value
+func appendText(out []string, v interface{}) []string {
+	if s, ok := v.(string); ok {
+		return append(out, s)
+	}
+	if m, ok := asMap(v); ok {
+		for _, k := range sortedKeys(m) {
+			out = appendText(out, m[k])
+		}
+		return out
+	}
+	for _, item := range asList(v) {
+		out = appendText(out, item)
+	}
+	return out
+}
+
+// build makes the postings of the documents from the tokens they have
+func (ix *SearchIndex) build() {
+	ix.postings = make(map[string]map[string]termCount)
+	for _, doc := range ix.Docs {
+		ix.add(doc)
+	}
+}
+
+// add puts a document and its tokens in the index
+func (ix *SearchIndex) add(doc *SearchDoc) {
+	ix.Docs[doc.Path] = doc
+	for token, tc := range doc.Counts {
+		docs, ok := ix.postings[token]
+		if !ok {
+			docs = make(map[string]termCount)
+			ix.postings[token] = docs
+			ix.sorted = false
+		}
+		docs[doc.Path] = tc
+	}
+}
+
+// remove takes a document and its tokens out of the index
+func (ix *SearchIndex) remove(path string) {
+	doc, ok := ix.Docs[path]
+	if !ok {
+		return
+	}
+	delete(ix.Docs, path)
+	for token := range doc.Counts {
+		delete(ix.postings[token], path)
+		if len(ix.postings[token]) == 0 {
+			delete(ix.postings, token)
+			ix.sorted = false
+		}
+	}
+}
+
+// Search finds the pages passing the filters of q that have every word of
+// its text, the last one as a prefix while it is being typed. Results are
+// ranked with BM25 on the body, matches in the title and front matter
+// weighing more. Without text, every page passing the filters is listed.
+func (ix *SearchIndex) Search(q SearchQuery) []SearchResult {
+	if ix.postings == nil {
+		ix.build()
+	}
+	if !ix.sorted {
+		ix.tokens = ix.tokens[:0]
+		for token := range ix.postings {
+			ix.tokens = append(ix.tokens, token)
+		}
+		sort.Strings(ix.tokens)
+		ix.sorted = true
+	}
+	now := time.Now()
+	pass := func(d *SearchDoc) bool {
+		if q.Section != "" && d.Section != q.Section || q.Lang != "" && d.Lang != q.Lang {
+			return false
+		}
+		if q.Status != "" && d.Status(now) != q.Status {
+			return false
+		}
+		if q.Taxonomy != "" && q.Term != "" {
+			for _, term := range d.Terms[q.Taxonomy] {
+				if TermKey(term) == TermKey(q.Term) {
+					return true
+				}
+			}
+			return false
+		}
+		return true
+	}
+
+	words := searchTokens(q.Text)
+	var results []SearchResult
+	if len(words) == 0 {
+		for _, d := range ix.Docs {
+			if pass(d) {
+				extract, _ := snippet(d.Body, nil)
+				results = append(results, SearchResult{Doc: d, Snippet: extract})
+			}
+		}
+		sort.Slice(results, func(i, j int) bool { return results[i].Doc.Path < results[j].Doc.Path })
+		return results
+	}
+
+	// The tokens each word matches: itself, or those it begins for the
+	// last word unless the text ends with a space
+	prefix := !strings.HasSuffix(q.Text, " ")
+	matches := make([][]string, len(words))
+	for i, w := range words {
+		if i < len(words)-1 || !prefix {
+			if _, ok := ix.postings[w]; ok {
+				matches[i] = []string{w}
+			}
+			continue
+		}
+		for j := sort.SearchStrings(ix.tokens, w); j < len(ix.tokens) && strings.HasPrefix(ix.tokens[j], w); j++ {
+			matches[i] = append(matches[i], ix.tokens[j])
+		}
+	}
+
+	avgLen := 1.0
+	if len(ix.Docs) > 0 {
+		total := 0
+		for _, d := range ix.Docs {
+			total += len(d.Body)
+		}
+		avgLen = math.Max(1, float64(total)/float64(len(ix.Docs)))
+	}
+	n := float64(len(ix.Docs))
+
+	scores := make(map[string]float64)
+	for i, tokens := range matches {
+		wordScores := make(map[string]float64)
+		for _, token := range tokens {
+			docs := ix.postings[token]
+			idf := math.Log(1 + (n-float64(len(docs))+0.5)/(float64(len(docs))+0.5))
+			for path, tc := range docs {
+				d := ix.Docs[path]
+				const k1, b = 1.2, 0.75
+				body := float64(tc.Body)
+				bm25 := body * (k1 + 1) / (body + k1*(1-b+b*float64(len(d.Body))/avgLen))
+				wordScores[path] = math.Max(wordScores[path], idf*(bm25+3*float64(tc.Title)+1.5*math.Min(float64(tc.Fields), 3)))
+			}
+		}
+		// Every word must match
+		next := make(map[string]float64)
+		for path, s := range wordScores {
+			if prev, ok := scores[path]; ok || i == 0 {
+				next[path] = prev + s
+			}
+		}
+		scores = next
+	}
+
+	phrase := strings.ToLower(strings.TrimSpace(q.Text))
+	for path, score := range scores {
+		d := ix.Docs[path]
+		if !pass(d) {
+			continue
+		}
+		if strings.Contains(strings.ToLower(d.Title), phrase) {
+			score *= 2
+		}
+		s, m := snippet(d.Body, func(token string) bool { return matchesAny(token, words, prefix) })
+		results = append(results, SearchResult{Doc: d, Score: score, Snippet: s, Matches: m})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Doc.Path < results[j].Doc.Path
+	})
+	return results
+}
+
+// Sections returns the sections of the indexed pages
+func (ix *SearchIndex) Sections() []string {
+	return ix.distinct(func(d *SearchDoc) []string { return []string{d.Section} })
+}
+
+// Terms returns the terms of a taxonomy the indexed pages use
+func (ix *SearchIndex) Terms(taxonomy string) []string {
+	return ix.distinct(func(d *SearchDoc) []string { return d.Terms[taxonomy] })
+}
+
+func (ix *SearchIndex) distinct(values func(*SearchDoc) []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, d := range ix.Docs {
+		for _, v := range values(d) {
+			if v != "" && !seen[v] {
+				seen[v] = true
+				out = append(out, v)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func matchesAny(token string, words []string, prefix bool) bool {
+	for i, w := range words {
+		if token == w || (prefix && i == len(words)-1 && strings.HasPrefix(token, w)) {
+			return true
+		}
+	}
+	return false
+}
+
+// snippetLength is about how long a snippet is, in bytes
+const snippetLength = 200
+
+// snippet returns an extract of text starting a little before the first
+// word match accepts, and the ranges of the words it accepts in it. Without
+// match, or without any accepted word, the extract is the start of text.
+func snippet(text string, match func(token string) bool) (string, [][2]int) {
+	first := -1
+	if match != nil {
+		searchWords(text, func(start, _ int, token string) {
+			if first < 0 && match(token) {
+				first = start
+			}
+		})
+	}
+
+	start := 0
+	if first > snippetLength/3 {
+		start = first - snippetLength/3
+		// Start on a word
+		for start < first && !unicode.IsSpace(runeAt(text, start)) {
+			start++
+		}
+		for start < len(text) && !utf8.RuneStart(text[start]) {
+			start++
+		}
+	}
+	end := start + snippetLength
+	if end >= len(text) {
+		end = len(text)
+	} else {
+		for end > start && !utf8.RuneStart(text[end]) {
+			end--
+		}
+	}
+
+	extract := text[start:end]
+	var matches [][2]int
+	if match != nil {
+		searchWords(extract, func(s, e int, token string) {
+			if match(token) {
+				matches = append(matches, [2]int{s, e})
+			}
+		})
+	}
+
+	// Collapse the white space, moving the matches along
+	var b strings.Builder
+	offsets := make([]int, len(extract)+1)
+	space := true
+	for i, r := range extract {
+		offsets[i] = b.Len()
+		if unicode.IsSpace(r) {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		b.WriteRune(r)
+		space = false
+		for j := 1; j < utf8.RuneLen(r); j++ {
+			offsets[i+j] = b.Len()
+		}
+	}
+	offsets[len(extract)] = b.Len()
+	for i := range matches {
+		matches[i] = [2]int{offsets[matches[i][0]], offsets[matches[i][1]]}
+	}
+
+	out := strings.TrimRight(b.String(), " ")
+	if start > 0 {
+		out = "…" + out
+		for i := range matches {
+			matches[i][0] += len("…")
+			matches[i][1] += len("…")
+		}
+	}
+	if end < len(text) {
+		out += "…"
+	}
+	return out, matches
+}
+
+func runeAt(text string, i int) rune {
+	r, _ := utf8.DecodeRuneInString(text[i:])
+	return r
+}
+
+// searchTokens returns the words of text as the index keeps them
+func searchTokens(text string) []string {
+	var tokens []string
+	searchWords(text, func(_, _ int, token string) { tokens = append(tokens, token) })
+	return tokens
+}
+
+// searchWords calls word with the byte range and the token of each word
+// of text. Tokens are lower case, without Arabic vowel marks or tatweel,
+// and with the forms of alef, teh marbuta and alef maksura written alike.
+// Chinese, Japanese and Korean characters are words on their own.
+func searchWords(text string, word func(start, end int, token string)) {
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		if start >= 0 && b.Len() > 0 {
+			word(start, end, b.String())
+		}
+		b.Reset()
+		start = -1
+	}
+	for i, r := range text {
+		switch {
+		case cms.IsCJK(r):
+			flush(i)
+			word(i, i+utf8.RuneLen(r), string(r))
+		case r == 'ـ' || (r >= 0x064B && r <= 0x065F) || r == 0x0670:
+			if start < 0 {
+				start = i
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r):
+			if start < 0 {
+				start = i
+			}
+			b.WriteRune(foldArabic(unicode.ToLower(r)))
+		default:
+			flush(i)
+		}
+	}
+	flush(len(text))
+}
+
+func foldArabic(r rune) rune {
+	switch r {
+	case 'أ', 'إ', 'آ', 'ٱ':
+		return 'ا'
+	case 'ة':
+		return 'ه'
+	case 'ى':
+		return 'ي'
+	}
+	return r
+}
+
+// SearchIndexName returns the names of the files to save the index of the
+// site and its changes in, telling sites apart by their directory
+func (c *SiteConfig) SearchIndexName() (index, changes string) {
+	h := fnv.New32a()
+	h.Write([]byte(c.Dir))
+	return fmt.Sprintf("search-%08x.json", h.Sum32()), fmt.Sprintf("search-%08x-changes.json", h.Sum32())
+}
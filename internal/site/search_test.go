@@ -0,0 +1,184 @@
+package site
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var searchSite = map[string]string{
+	"content/posts/go.md":      "---\ntitle: Go Tips\ntags: [Go]\n---\nLearn about goroutines and channels.\n",
+	"content/posts/rust.md":    "---\ntitle: Rust\ndescription: Not Go\n---\nOwnership and borrowing, unlike Go tips and tricks.\n",
+	"content/posts/arabic.md":  "---\ntitle: مقالة\n---\nعن الإسلام والكتابة\n",
+	"content/docs/install.md":  "---\ntitle: Install\ndraft: true\n---\nInstall the tool with go install.\n",
+	"content/news/future.md":   "---\ntitle: Future\npublishDate: 2999-01-01\n---\nComing soon.\n",
+	"content/news/japanese.md": "---\ntitle: 日本\n---\n日本語の文章です。\n",
+}
+
+// searchRels returns the pages of results, relative to the content directory
+func searchRels(results []SearchResult) string {
+	rels := make([]string, len(results))
+	for i, r := range results {
+		rels[i] = r.Doc.Rel
+	}
+	return strings.Join(rels, ", ")
+}
+
+func newSearchIndex(t *testing.T, dir string) (*SiteConfig, *SearchIndex) {
+	t.Helper()
+	c, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix := c.NewSearchIndex()
+	if _, err := ix.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	return c, ix
+}
+
+func TestSearch(t *testing.T) {
+	_, ix := newSearchIndex(t, writeSite(t, searchSite))
+
+	tests := []struct {
+		name  string
+		query SearchQuery
+		want  string
+	}{
+		{"title before body", SearchQuery{Text: "go tips"}, "posts/go.md, posts/rust.md"},
+		{"every word", SearchQuery{Text: "install go"}, "docs/install.md"},
+		{"front matter", SearchQuery{Text: "not"}, "posts/rust.md"},
+		{"prefix of the last word", SearchQuery{Text: "gorout"}, "posts/go.md"},
+		{"whole last word after a space", SearchQuery{Text: "gorout "}, ""},
+		{"only the last word is a prefix", SearchQuery{Text: "own borrowing"}, ""},
+		{"case", SearchQuery{Text: "CHANNELS"}, "posts/go.md"},
+		{"arabic letter forms", SearchQuery{Text: "الاسلام"}, "posts/arabic.md"},
+		{"cjk characters", SearchQuery{Text: "文章"}, "news/japanese.md"},
+		{"section", SearchQuery{Text: "go", Section: "docs"}, "docs/install.md"},
+		{"term", SearchQuery{Taxonomy: "tags", Term: "go"}, "posts/go.md"},
+		{"draft", SearchQuery{Status: StatusDraft}, "docs/install.md"},
+		{"scheduled", SearchQuery{Status: StatusScheduled}, "news/future.md"},
+		{"all by path", SearchQuery{Section: "news"}, "news/future.md, news/japanese.md"},
+		{"no match", SearchQuery{Text: "python"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchRels(ix.Search(tt.query)); got != tt.want {
+				t.Errorf("Search(%+v) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	text := strings.Repeat("filler ", 40) + "the Needle is here"
+	got, matches := snippet(text, func(token string) bool { return token == "needle" })
+	if !strings.HasPrefix(got, "…") || len(matches) != 1 {
+		t.Fatalf("snippet = %q, matches %v", got, matches)
+	}
+	if word := got[matches[0][0]:matches[0][1]]; word != "Needle" {
+		t.Errorf("match = %q, want Needle", word)
+	}
+}
+
+func TestSearchUpdate(t *testing.T) {
+	dir := writeSite(t, searchSite)
+	c, ix := newSearchIndex(t, dir)
+	path := func(rel string) string { return filepath.Join(dir, "content", filepath.FromSlash(rel)) }
+
+	if changed, err := ix.Update(c); err != nil || len(changed) != 0 {
+		t.Fatalf("unchanged site: changed %v, %v", changed, err)
+	}
+
+	if err := os.WriteFile(path("posts/go.md"), []byte("---\ntitle: Go Tips\n---\nAll about generics now.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path("posts/rust.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path("posts/zig.md"), []byte("---\ntitle: Zig\n---\nComptime.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := ix.Update(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{path("posts/go.md"), path("posts/rust.md"), path("posts/zig.md")}
+	if strings.Join(changed, "\n") != strings.Join(want, "\n") {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+
+	tests := []struct {
+		text, want string
+	}{
+		{"generics", "posts/go.md"},
+		{"goroutines", ""},
+		{"borrowing", ""},
+		{"comptime", "posts/zig.md"},
+	}
+	for _, tt := range tests {
+		if got := searchRels(ix.Search(SearchQuery{Text: tt.text})); got != tt.want {
+			t.Errorf("Search(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestReadSearchIndex(t *testing.T) {
+	dir := writeSite(t, searchSite)
+	c, ix := newSearchIndex(t, dir)
+	var index, changes bytes.Buffer
+	if err := ix.Write(&index); err != nil {
+		t.Fatal(err)
+	}
+
+	goPage := filepath.Join(dir, "content", "posts", "go.md")
+	if err := os.WriteFile(goPage, []byte("---\ntitle: Go Tips\n---\nAll about generics now.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "content", "posts", "rust.md")); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := ix.Update(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.WriteChanges(&changes, changed); err != nil {
+		t.Fatal(err)
+	}
+	changes.WriteString(`{"Path": "cut short`)
+
+	read, err := c.ReadSearchIndex(bytes.NewReader(index.Bytes()), &changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read.Docs) != len(ix.Docs) {
+		t.Errorf("read %d documents, want %d", len(read.Docs), len(ix.Docs))
+	}
+	tests := []struct {
+		text, want string
+	}{
+		{"generics", "posts/go.md"},
+		{"goroutines", ""},
+		{"borrowing", ""},
+		{"install", "docs/install.md"},
+	}
+	for _, tt := range tests {
+		if got := searchRels(read.Search(SearchQuery{Text: tt.text})); got != tt.want {
+			t.Errorf("Search(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+	if changed, err := read.Update(c); err != nil || len(changed) != 0 {
+		t.Errorf("changed after reading = %v, %v", changed, err)
+	}
+
+	// The index of another site is not used
+	other, err := Load(writeSite(t, nil), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read, err := other.ReadSearchIndex(bytes.NewReader(index.Bytes()), nil); err != nil || len(read.Docs) != 0 {
+		t.Errorf("other site read %d documents, %v", len(read.Docs), err)
+	}
+}
@@ -0,0 +1,53 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+)
+
+// Slug turns a title into a slug, written in ASCII when the site asks for
+// transliterated slugs
+func (c *SiteConfig) Slug(title string) string {
+	return cms.Slugify(title, c.TransliterateSlugs)
+}
+
+// UniqueSlug returns slug, or slug-2, slug-3 and so on, so that no file
+// of dir and no other page of the section has it as name or slug. self is
+// the path of the page being named, whose files and translations don't
+// count.
+func (c *SiteConfig) UniqueSlug(pages []*Page, dir, section, slug, self string) string {
+	taken := make(map[string]bool)
+	_, selfBase := c.SplitLang(filepath.Base(self))
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		_, base := c.SplitLang(entry.Name())
+		if filepath.Join(dir, entry.Name()) == filepath.Dir(self) || (filepath.Dir(self) == dir && base == selfBase) {
+			continue
+		}
+		taken[strings.TrimSuffix(base, filepath.Ext(base))] = true
+	}
+
+	var key string
+	if p := FindPage(pages, self); p != nil {
+		key = p.TranslationKey()
+	}
+	for _, p := range pages {
+		if p.Section() != section || p.Path == self || (key != "" && p.TranslationKey() == key) {
+			continue
+		}
+		taken[contentBaseName(p)] = true
+		if s := getString(p.Meta, "slug"); s != "" {
+			taken[s] = true
+		}
+	}
+
+	unique := slug
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", slug, i)
+	}
+	return unique
+}
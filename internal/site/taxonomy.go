@@ -0,0 +1,185 @@
+package site
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Taxonomy is a taxonomy of the site with the terms used in its content
+type Taxonomy struct {
+	Singular string // e.g. tag
+	Plural   string // e.g. tags, the front matter key
+	Terms    []*Term
+}
+
+// Term is a term of a taxonomy with the pages using it
+type Term struct {
+	Name     string   // The most used spelling
+	Spelling []string // Every spelling in use, e.g. Go and go
+	Pages    []*Page
+}
+
+// TaxonomyIndex collects the terms of every taxonomy of the site from the
+// front matter of pages. Terms that Hugo treats as the same, e.g. "Go" and
+// "go", are grouped together. Terms are sorted by page count.
+func (c *SiteConfig) TaxonomyIndex(pages []*Page) []*Taxonomy {
+	var index []*Taxonomy
+	for singular, plural := range c.Taxonomies {
+		t := &Taxonomy{Singular: singular, Plural: plural}
+
+		byKey := make(map[string]*Term)
+		uses := make(map[string]int) // spelling -> pages
+		for _, p := range pages {
+			seen := make(map[*Term]bool)
+			for _, name := range pageTerms(p.Meta, plural) {
+				key := TermKey(name)
+				term := byKey[key]
+				if term == nil {
+					term = &Term{Name: name}
+					byKey[key] = term
+					t.Terms = append(t.Terms, term)
+				}
+				if uses[name] == 0 {
+					term.Spelling = append(term.Spelling, name)
+				}
+				uses[name]++
+				if !seen[term] {
+					term.Pages = append(term.Pages, p)
+					seen[term] = true
+				}
+			}
+		}
+
+		for _, term := range t.Terms {
+			sort.SliceStable(term.Spelling, func(i, j int) bool {
+				return uses[term.Spelling[i]] > uses[term.Spelling[j]]
+			})
+			term.Name = term.Spelling[0]
+		}
+		sort.SliceStable(t.Terms, func(i, j int) bool {
+			a, b := t.Terms[i], t.Terms[j]
+			if len(a.Pages) != len(b.Pages) {
+				return len(a.Pages) > len(b.Pages)
+			}
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		})
+		index = append(index, t)
+	}
+
+	sort.Slice(index, func(i, j int) bool { return index[i].Plural < index[j].Plural })
+	return index
+}
+
+// FindTaxonomy returns the taxonomy whose front matter key is plural
+func FindTaxonomy(index []*Taxonomy, plural string) *Taxonomy {
+	for _, t := range index {
+		if strings.EqualFold(t.Plural, plural) {
+			return t
+		}
+	}
+	return nil
+}
+
+// Names returns the names of the terms, most used first
+func (t *Taxonomy) Names() []string {
+	names := make([]string, len(t.Terms))
+	for i, term := range t.Terms {
+		names[i] = term.Name
+	}
+	return names
+}
+
+// TermKey returns what identifies a term the way Hugo compares them: case
+// and spacing don't matter
+func TermKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), "-"))
+}
+
+// pageTerms returns the terms of a page for a taxonomy, which can be a
+// single term or a list
+func pageTerms(meta map[string]interface{}, plural string) []string {
+	var terms []string
+	for _, term := range getStrings(meta, plural) {
+		if term = strings.TrimSpace(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// RenameTerm replaces the terms in from by to in the front matter of every
+// page using them, which merges them when there are several. An empty to
+// removes the terms. It returns the number of files changed.
+func RenameTerm(pages []*Page, plural string, from []string, to string) (int, error) {
+	keys := make(map[string]bool, len(from))
+	for _, name := range from {
+		keys[TermKey(name)] = true
+	}
+
+	changed := 0
+	for _, p := range pages {
+		uses := false
+		for _, name := range pageTerms(p.Meta, plural) {
+			uses = uses || keys[TermKey(name)]
+		}
+		if !uses {
+			continue
+		}
+
+		err := UpdatePage(p.Path, func(meta map[string]interface{}) error {
+			v, key := lookup(meta, plural)
+			if key == "" {
+				return nil
+			}
+			terms := replaceTerms(pageTerms(meta, plural), keys, to)
+			if len(terms) == 0 {
+				delete(meta, key)
+				return nil
+			}
+			meta[key] = TermsLike(terms, v)
+			return nil
+		})
+		if err != nil {
+			return changed, fmt.Errorf("%s: %w", p.Rel, err)
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// replaceTerms replaces the terms matching keys by to, without adding it
+// twice
+func replaceTerms(terms []string, keys map[string]bool, to string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, name := range terms {
+		if keys[TermKey(name)] {
+			name = to
+		}
+		if name == "" || seen[TermKey(name)] {
+			continue
+		}
+		seen[TermKey(name)] = true
+		out = append(out, name)
+	}
+	return out
+}
+
+// TermsLike returns terms in the same form as the value they replace: a
+// single string stays a string while it holds one term
+func TermsLike(terms []string, like interface{}) interface{} {
+	switch like.(type) {
+	case string:
+		if len(terms) == 1 {
+			return terms[0]
+		}
+	case []string:
+		return terms
+	}
+	list := make([]interface{}, len(terms))
+	for i, term := range terms {
+		list[i] = term
+	}
+	return list
+}
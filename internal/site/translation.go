@@ -0,0 +1,100 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+)
+
+// Translations returns the pages that translate p, including p, by
+// language
+func Translations(pages []*Page, p *Page) map[string]*Page {
+	key := p.TranslationKey()
+	out := map[string]*Page{p.Lang: p}
+	for _, other := range pages {
+		if other.TranslationKey() == key {
+			if _, ok := out[other.Lang]; !ok {
+				out[other.Lang] = other
+			}
+		}
+	}
+	return out
+}
+
+// FindPage returns the page read from path
+func FindPage(pages []*Page, path string) *Page {
+	for _, p := range pages {
+		if p.Path == path {
+			return p
+		}
+	}
+	return nil
+}
+
+// TranslationPath returns where the translation of p into lang goes: the
+// same path in the language's content directory when it has its own, or
+// else next to p with the language in the file name, e.g. post.ar.md.
+func (c *SiteConfig) TranslationPath(p *Page, lang string) string {
+	target, _ := c.Language(lang)
+	source, _ := c.Language(p.Lang)
+
+	key := p.key
+	if key == "" {
+		key = p.Rel
+	}
+	if target.ContentDir != source.ContentDir {
+		return filepath.Join(c.Dir, target.ContentDir, filepath.FromSlash(key))
+	}
+
+	dir := filepath.Dir(p.Path)
+	base := filepath.Base(key)
+	if lang == c.DefaultContentLanguage {
+		return filepath.Join(dir, base)
+	}
+	ext := filepath.Ext(base)
+	return filepath.Join(dir, base[:len(base)-len(ext)]+"."+lang+ext)
+}
+
+// Translate creates the translation of p into lang with a copy of its front
+// matter, for the translator to fill in. It returns the path of the new
+// file. The url and aliases are left out, as the translation would collide
+// with p, and the slug is emptied for one in the new language.
+func (c *SiteConfig) Translate(p *Page, lang string) (string, error) {
+	path := c.TranslationPath(p, lang)
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("%s already exists", filepath.Base(path))
+	}
+
+	content, err := os.ReadFile(p.Path)
+	if err != nil {
+		return "", err
+	}
+	md, err := cms.ParseMD(string(content))
+	if err != nil {
+		return "", err
+	}
+	md.Body = ""
+	for k := range md.MetaData {
+		switch strings.ToLower(k) {
+		case "url", "aliases":
+			delete(md.MetaData, k)
+		case "slug":
+			md.MetaData[k] = ""
+		}
+	}
+
+	out, err := md.ToString()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(out), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,271 @@
+package site
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+)
+
+// AllTypes is the key of the rules every page follows
+const AllTypes = "*"
+
+// Rules are the front matter rules of a site, read from
+// .bayan/validation.toml (or .yaml, .json) at its root. They are grouped
+// by content type, which is the type of the front matter or else the
+// section, with the rules under "*" applying to every type:
+//
+//	[posts.description]
+//	required = true
+//	maxLength = 160
+//
+//	[posts.categories]
+//	allowed = ["news", "guides"]
+//
+//	["*".date]
+//	notFuture = true
+//
+// Only regular pages are checked, not home and section pages.
+type Rules struct {
+	Path  string
+	Types map[string]map[string]FieldRule // type -> key -> rule
+}
+
+// FieldRule is what the value of a front matter key must be
+type FieldRule struct {
+	Required  bool
+	Allowed   []string       // The values, or items of a list, it can have
+	Pattern   *regexp.Regexp // Strings, or items of a list, must match it
+	MinLength int            // In characters for a string, items for a list
+	MaxLength int
+	After     time.Time // Dates must be after, before, or not in the future
+	Before    time.Time
+	NotFuture bool
+}
+
+// ValidationError is a front matter value of a page breaking a rule
+type ValidationError struct {
+	Page    *Page
+	Key     string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Key + " " + e.Message
+}
+
+// LoadRules reads the front matter rules of the site. A site without rules
+// gets nil.
+func (c *SiteConfig) LoadRules() (*Rules, error) {
+	var path string
+	for _, ext := range []string{"toml", "yaml", "yml", "json"} {
+		candidate := filepath.Join(c.Dir, ".bayan", "validation."+ext)
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+			break
+		}
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := ReadData(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	r := &Rules{Path: path, Types: make(map[string]map[string]FieldRule)}
+	for typ, v := range data.Data {
+		fields, ok := asMap(v)
+		if !ok {
+			return nil, fmt.Errorf("%s: %s is not a table of fields", filepath.Base(path), typ)
+		}
+		r.Types[typ] = make(map[string]FieldRule)
+		for key, v := range fields {
+			m, ok := asMap(v)
+			if !ok {
+				return nil, fmt.Errorf("%s: %s.%s is not a table of rules", filepath.Base(path), typ, key)
+			}
+			rule, err := readFieldRule(m)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s.%s: %w", filepath.Base(path), typ, key, err)
+			}
+			r.Types[typ][key] = rule
+		}
+	}
+	return r, nil
+}
+
+func readFieldRule(m map[string]interface{}) (FieldRule, error) {
+	rule := FieldRule{
+		Required:  getBool(m, "required"),
+		Allowed:   getStrings(m, "allowed"),
+		MinLength: getInt(m, "minLength"),
+		MaxLength: getInt(m, "maxLength"),
+		NotFuture: getBool(m, "notFuture"),
+	}
+	if pattern := getString(m, "pattern"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return rule, err
+		}
+		rule.Pattern = re
+	}
+	for key, t := range map[string]*time.Time{"after": &rule.After, "before": &rule.Before} {
+		v, found := lookup(m, key)
+		if found == "" {
+			continue
+		}
+		switch v := v.(type) {
+		case time.Time:
+			*t = v
+		default:
+			parsed, err := cms.ParseDate(fmt.Sprint(v), nil)
+			if err != nil {
+				return rule, fmt.Errorf("%s: %w", key, err)
+			}
+			*t = parsed
+		}
+	}
+	return rule, nil
+}
+
+// Type returns the content type of p: its type, or else its section
+func (p *Page) Type() string {
+	if t := getString(p.Meta, "type"); t != "" {
+		return t
+	}
+	return p.Section()
+}
+
+// Validate checks the front matter of p against the rules of its type and
+// those of every type. Rules of its type win for keys both set.
+func (r *Rules) Validate(p *Page) []ValidationError {
+	if r == nil || p.Kind() != "page" {
+		return nil
+	}
+	fields := make(map[string]FieldRule)
+	for key, rule := range r.Types[AllTypes] {
+		fields[key] = rule
+	}
+	for key, rule := range r.Types[p.Type()] {
+		fields[key] = rule
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []ValidationError
+	for _, key := range keys {
+		for _, msg := range fields[key].check(p.Meta, key) {
+			errs = append(errs, ValidationError{Page: p, Key: key, Message: msg})
+		}
+	}
+	return errs
+}
+
+// check returns how the value of key in meta breaks the rule
+func (rule FieldRule) check(meta map[string]interface{}, key string) []string {
+	v, found := Get(meta, key)
+	if !found || isEmpty(v) {
+		if rule.Required {
+			return []string{"is required"}
+		}
+		return nil
+	}
+
+	var msgs []string
+	items := asList(v)
+	isList := items != nil
+	if !isList {
+		items = []interface{}{v}
+	}
+
+	length := len(items)
+	unit := "items"
+	if s, ok := v.(string); ok {
+		length = utf8.RuneCountInString(s)
+		unit = "characters"
+	}
+	if rule.MinLength > 0 && length < rule.MinLength {
+		msgs = append(msgs, fmt.Sprintf("has %d %s, at least %d needed", length, unit, rule.MinLength))
+	}
+	if rule.MaxLength > 0 && length > rule.MaxLength {
+		msgs = append(msgs, fmt.Sprintf("has %d %s, at most %d allowed", length, unit, rule.MaxLength))
+	}
+
+	for _, item := range items {
+		s := fmt.Sprint(item)
+		if len(rule.Allowed) > 0 && !containsString(rule.Allowed, s) {
+			msgs = append(msgs, fmt.Sprintf("can't be %q, only %s", s, strings.Join(rule.Allowed, ", ")))
+		}
+		if rule.Pattern != nil && !rule.Pattern.MatchString(s) {
+			msgs = append(msgs, fmt.Sprintf("%q doesn't match %s", s, rule.Pattern))
+		}
+	}
+
+	if rule.After.IsZero() && rule.Before.IsZero() && !rule.NotFuture {
+		return msgs
+	}
+	t, ok := v.(time.Time)
+	if !ok {
+		parsed, err := cms.ParseDate(fmt.Sprint(v), nil)
+		if err != nil {
+			return append(msgs, "is not a date")
+		}
+		t = parsed
+	}
+	if !rule.After.IsZero() && !t.After(rule.After) {
+		msgs = append(msgs, "must be after "+cms.FormatDate(rule.After))
+	}
+	if !rule.Before.IsZero() && !t.Before(rule.Before) {
+		msgs = append(msgs, "must be before "+cms.FormatDate(rule.Before))
+	}
+	if rule.NotFuture && t.After(time.Now()) {
+		msgs = append(msgs, "is in the future")
+	}
+	return msgs
+}
+
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	}
+	if m, ok := asMap(v); ok {
+		return len(m) == 0
+	}
+	if list := asList(v); list != nil {
+		return len(list) == 0
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePages checks every page against the rules, by path
+func (r *Rules) ValidatePages(pages []*Page) []ValidationError {
+	var errs []ValidationError
+	for _, p := range pages {
+		errs = append(errs, r.Validate(p)...)
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Page.Path < errs[j].Page.Path })
+	return errs
+}
@@ -0,0 +1,152 @@
+package site
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFieldRuleCheck(t *testing.T) {
+	date := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02", s)
+		return t
+	}
+
+	tests := []struct {
+		name  string
+		rule  FieldRule
+		value interface{} // nil for a missing key
+		want  string      // The messages, joined with "; "
+	}{
+		{"required", FieldRule{Required: true}, nil, "is required"},
+		{"required and blank", FieldRule{Required: true}, "  ", "is required"},
+		{"required and empty list", FieldRule{Required: true}, []interface{}{}, "is required"},
+		{"optional and missing", FieldRule{MinLength: 3}, nil, ""},
+		{"too short", FieldRule{MinLength: 5}, "مرحبا", ""},
+		{"too short by characters", FieldRule{MinLength: 6}, "مرحبا", "has 5 characters, at least 6 needed"},
+		{"too long", FieldRule{MaxLength: 3}, "four", "has 4 characters, at most 3 allowed"},
+		{"too many items", FieldRule{MaxLength: 1}, []interface{}{"a", "b"}, "has 2 items, at most 1 allowed"},
+		{"allowed", FieldRule{Allowed: []string{"news", "guides"}}, "news", ""},
+		{"not allowed", FieldRule{Allowed: []string{"news", "guides"}}, "blog", `can't be "blog", only news, guides`},
+		{"items not allowed", FieldRule{Allowed: []string{"news"}}, []interface{}{"news", "blog"}, `can't be "blog", only news`},
+		{"pattern", FieldRule{Pattern: regexp.MustCompile(`^[a-z-]+$`)}, "go-tips", ""},
+		{"pattern broken", FieldRule{Pattern: regexp.MustCompile(`^[a-z-]+$`)}, "Go Tips", `"Go Tips" doesn't match ^[a-z-]+$`},
+		{"after", FieldRule{After: date("2020-01-01")}, date("2019-06-01"), "must be after 2020-01-01"},
+		{"before", FieldRule{Before: date("2020-01-01")}, "2021-02-03", "must be before 2020-01-01"},
+		{"between", FieldRule{After: date("2020-01-01"), Before: date("2021-01-01")}, date("2020-06-01"), ""},
+		{"not a date", FieldRule{NotFuture: true}, "soon", "is not a date"},
+		{"future", FieldRule{NotFuture: true}, time.Now().Add(time.Hour), "is in the future"},
+		{"past", FieldRule{NotFuture: true}, "2001-01-01", ""},
+		{
+			name:  "every message",
+			rule:  FieldRule{MinLength: 3, Allowed: []string{"a"}, Pattern: regexp.MustCompile(`^a$`)},
+			value: []interface{}{"b"},
+			want:  `has 1 items, at least 3 needed; can't be "b", only a; "b" doesn't match ^a$`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := map[string]interface{}{}
+			if tt.value != nil {
+				meta["key"] = tt.value
+			}
+			if got := strings.Join(tt.rule.check(meta, "key"), "; "); got != tt.want {
+				t.Errorf("check = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := writeSite(t, map[string]string{
+		".bayan/validation.toml": `["*".description]
+required = true
+
+[posts.description]
+maxLength = 10
+
+[posts.categories]
+allowed = ["news", "guides"]
+
+[notes."params.mood"]
+allowed = ["happy"]
+`,
+		"content/posts/_index.md":  "---\ntitle: Posts\n---\n",
+		"content/posts/ok.md":      "---\ndescription: Short\ncategories: [news]\n---\n",
+		"content/posts/long.md":    "---\ndescription: Far too long\ncategories: [blog]\n---\n",
+		"content/posts/missing.md": "---\ntitle: Missing\n---\n",
+		"content/docs/missing.md":  "---\ntitle: Missing\n---\n",
+		"content/docs/page.md":     "---\ndescription: Any length is fine here\ncategories: [blog]\n---\n",
+		"content/docs/note.md":     "---\ntype: notes\ndescription: Note\nparams:\n  mood: sad\n---\n",
+	})
+	c, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := c.LoadRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages, err := c.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, e := range rules.ValidatePages(pages) {
+		got = append(got, e.Page.Rel+": "+e.Error())
+	}
+	want := []string{
+		`docs/missing.md: description is required`,
+		`docs/note.md: params.mood can't be "sad", only happy`,
+		`posts/long.md: categories can't be "blog", only news, guides`,
+		`posts/long.md: description has 12 characters, at most 10 allowed`,
+		// posts/missing.md follows the description rule of posts instead
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string // Content of .bayan/validation.toml, "" for none
+		err   string // Part of the error, "" for none
+	}{
+		{"none", "", ""},
+		{"dates", "[posts.date]\nafter = 2020-01-01\nbefore = \"2030-01-01\"\n", ""},
+		{"not a table of fields", "posts = 1\n", "posts is not a table of fields"},
+		{"not a table of rules", "[posts]\ntitle = true\n", "posts.title is not a table of rules"},
+		{"bad pattern", "[posts.title]\npattern = \"[\"\n", "posts.title"},
+		{"bad date", "[posts.date]\nafter = \"someday\"\n", "after"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{"content/_index.md": ""}
+			if tt.rules != "" {
+				files[".bayan/validation.toml"] = tt.rules
+			}
+			c, err := Load(writeSite(t, files), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			rules, err := c.LoadRules()
+			switch {
+			case tt.err != "":
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("err = %v, want %q", err, tt.err)
+				}
+			case err != nil:
+				t.Errorf("err = %v", err)
+			case tt.rules == "" && rules != nil:
+				t.Errorf("rules = %+v without a file", rules)
+			case tt.rules != "" && rules == nil:
+				t.Errorf("no rules read")
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package site
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lookup finds k in m ignoring case, returning the value and the key as
+// written. key is empty when k is missing.
+func lookup(m map[string]interface{}, k string) (interface{}, string) {
+	if v, ok := m[k]; ok {
+		return v, k
+	}
+	for key, v := range m {
+		if strings.EqualFold(key, k) {
+			return v, key
+		}
+	}
+	return nil, ""
+}
+
+// Get returns the value at a dotted key such as "params.author.name",
+// ignoring case.
+func Get(m map[string]interface{}, key string) (interface{}, bool) {
+	var cur interface{} = m
+	for _, part := range strings.Split(key, ".") {
+		cm, ok := asMap(cur)
+		if !ok {
+			return nil, false
+		}
+		v, found := lookup(cm, part)
+		if found == "" {
+			return nil, false
+		}
+		cur = v
+	}
+	return cur, true
+}
+
+func asMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, item := range m {
+			out[fmt.Sprint(k)] = item
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func asList(v interface{}) []interface{} {
+	switch l := v.(type) {
+	case []interface{}:
+		return l
+	case []map[string]interface{}:
+		out := make([]interface{}, len(l))
+		for i, item := range l {
+			out[i] = item
+		}
+		return out
+	case []string:
+		out := make([]interface{}, len(l))
+		for i, item := range l {
+			out[i] = item
+		}
+		return out
+	}
+	return nil
+}
+
+func getString(m map[string]interface{}, k string) string {
+	v, _ := lookup(m, k)
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func getBool(m map[string]interface{}, k string) bool {
+	v, _ := lookup(m, k)
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		parsed, _ := strconv.ParseBool(b)
+		return parsed
+	}
+	return false
+}
+
+func getInt(m map[string]interface{}, k string) int {
+	v, _ := lookup(m, k)
+	return toInt(v)
+}
+
+func toInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case uint64:
+		return int(n)
+	case float64:
+		return int(n)
+	case string:
+		i, _ := strconv.Atoi(n)
+		return i
+	}
+	return 0
+}
+
+// getStrings reads a value that may be a single string or a list
+func getStrings(m map[string]interface{}, k string) []string {
+	v, _ := lookup(m, k)
+	if s, ok := v.(string); ok {
+		if s == "" {
+			return nil
+		}
+		return []string{s}
+	}
+	var out []string
+	for _, item := range asList(v) {
+		out = append(out, fmt.Sprint(item))
+	}
+	return out
+}
+
+func getMap(m map[string]interface{}, k string) map[string]interface{} {
+	v, _ := lookup(m, k)
+	if mm, ok := asMap(v); ok {
+		return mm
+	}
+	return map[string]interface{}{}
+}
@@ -0,0 +1,119 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+)
+
+// Save writes changes, keyed by dotted key such as "params.description",
+// back to the config files. Each key goes to the file that sets it or its
+// closest parent, new keys go to the main config file, and a nil value
+// removes the key. Files keep their format and formatting. The config is
+// reloaded afterwards.
+func (c *SiteConfig) Save(changes map[string]interface{}) error {
+	byFile := make(map[string]map[string]interface{})
+	for key, val := range changes {
+		file := c.SourceOf(key)
+		if file == "" {
+			file = c.MainFile()
+		}
+
+		// Make the key relative to where the file is mounted, e.g. keys of
+		// params.toml lose their "params." prefix
+		parts := strings.Split(key, ".")
+		if mount := c.mounts[file]; mount != "" {
+			parts = parts[len(strings.Split(mount, ".")):]
+		}
+
+		if byFile[file] == nil {
+			byFile[file] = make(map[string]interface{})
+		}
+		byFile[file][strings.Join(parts, ".")] = val
+	}
+
+	for file, fileChanges := range byFile {
+		if err := writeChanges(file, fileChanges); err != nil {
+			return err
+		}
+	}
+
+	reloaded, err := Load(c.Dir, c.Environment)
+	if err != nil {
+		return err
+	}
+	reloaded.pages = c.pages // The content didn't change
+	*c = *reloaded
+	return nil
+}
+
+// MainFile returns the file holding the root configuration, which is
+// hugo.toml when the site has none yet.
+func (c *SiteConfig) MainFile() string {
+	for _, file := range c.Files {
+		if c.mounts[file] == "" {
+			return file
+		}
+	}
+	return filepath.Join(c.Dir, "hugo.toml")
+}
+
+func writeChanges(file string, changes map[string]interface{}) error {
+	content, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	doc, err := cms.ParseData(string(content), configFormat(file))
+	if err != nil {
+		return err
+	}
+	for key, val := range changes {
+		SetPath(doc.Data, key, val)
+	}
+
+	out, err := doc.ToString()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(out), 0644)
+}
+
+// SetPath sets the value at a dotted key, creating maps on the way and
+// matching existing keys case insensitively. A nil value removes the key.
+func SetPath(m map[string]interface{}, key string, val interface{}) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		v, existing := lookup(m, part)
+		child, ok := asMap(v)
+		if !ok {
+			if val == nil {
+				return
+			}
+			child = make(map[string]interface{})
+		}
+		if existing == "" {
+			existing = part
+		}
+		m[existing] = child
+		m = child
+	}
+
+	last := parts[len(parts)-1]
+	_, existing := lookup(m, last)
+	if val == nil {
+		if existing != "" {
+			delete(m, existing)
+		}
+		return
+	}
+	if existing == "" {
+		existing = last
+	}
+	m[existing] = val
+}
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// showAliasCollisions lists the aliases that are the URL of another page
+// or an alias of another page too. Tapping one opens the page with the
+// alias.
+func showAliasCollisions(w fyne.Window, collisions []site.AliasCollision, open func(path string)) {
+	const title = "Aliases"
+	if len(collisions) == 0 {
+		dialog.ShowInformation(title, "No alias collides with a page.", w)
+		return
+	}
+
+	var d dialog.Dialog
+	list := widget.NewList(
+		func() int { return len(collisions) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			c := collisions[id]
+			box := o.(*fyne.Container)
+			box.Objects[0].(*widget.Label).SetText(fmt.Sprintf("%s: %s", c.Page.Rel, c.Alias))
+			reason := "also an alias of " + c.Other.Rel
+			if c.Real {
+				reason = "the URL of " + c.Other.Rel
+			}
+			box.Objects[1].(*widget.Label).SetText(reason)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		d.Hide()
+		open(collisions[id].Page.Path)
+	}
+
+	message := widget.NewLabel(fmt.Sprintf("%d aliases collide. Remove them, or the redirect and the page overwrite each other.", len(collisions)))
+	d = dialog.NewCustom(title, "Close", container.NewBorder(message, nil, nil, nil, list), w)
+	d.Resize(fyne.NewSize(600, 450))
+	d.Show()
+}
@@ -0,0 +1,246 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// ContentCalendar shows the pages of the site by month on the day they
+// get published or expire, marked as drafts, scheduled, published or
+// expired, and reschedules them
+type ContentCalendar struct {
+	Site       *site.SiteConfig
+	OnOpenFile func(string)
+
+	window    fyne.Window
+	container *fyne.Container
+	monthLbl  *widget.Label
+	grid      *fyne.Container
+	undated   *fyne.Container
+
+	month time.Time // The first day of the month shown
+	pages []*site.Page
+}
+
+// NewContentCalendar creates the calendar screen of a site. The content is
+// read when it is refreshed.
+func NewContentCalendar(w fyne.Window, cfg *site.SiteConfig, onOpenFile func(string)) *ContentCalendar {
+	now := time.Now()
+	c := &ContentCalendar{
+		Site:       cfg,
+		OnOpenFile: onOpenFile,
+		window:     w,
+		monthLbl:   widget.NewLabelWithStyle("", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		grid:       container.NewGridWithColumns(7),
+		undated:    container.NewVBox(),
+		month:      time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local),
+	}
+
+	prevBtn := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		c.month = c.month.AddDate(0, -1, 0)
+		c.rebuild()
+	})
+	nextBtn := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
+		c.month = c.month.AddDate(0, 1, 0)
+		c.rebuild()
+	})
+	todayBtn := widget.NewButton("Today", func() {
+		now := time.Now()
+		c.month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		c.rebuild()
+	})
+	refreshBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), c.refresh)
+
+	legend := container.NewHBox()
+	for _, status := range []string{site.StatusDraft, site.StatusScheduled, site.StatusPublished, site.StatusExpired} {
+		btn := widget.NewButton(status, nil)
+		btn.Importance = statusImportance(status)
+		btn.Disable()
+		legend.Add(btn)
+	}
+
+	topBar := container.NewVBox(
+		container.NewBorder(nil, nil, container.NewHBox(prevBtn, todayBtn, nextBtn), refreshBtn, c.monthLbl),
+		legend,
+	)
+
+	side := container.NewBorder(
+		widget.NewLabelWithStyle("Drafts without a date", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		nil, nil, nil,
+		container.NewVScroll(c.undated),
+	)
+	split := container.NewHSplit(container.NewVScroll(c.grid), side)
+	split.Offset = 0.8
+
+	c.container = container.NewPadded(container.NewBorder(topBar, nil, nil, nil, split))
+	return c
+}
+
+// GetUI returns the container for this component
+func (c *ContentCalendar) GetUI() fyne.CanvasObject {
+	return c.container
+}
+
+// refresh reads the content again
+func (c *ContentCalendar) refresh() {
+	pages, err := c.Site.Pages()
+	if err != nil {
+		dialog.ShowError(err, c.window)
+	}
+	c.pages = pages
+	c.rebuild()
+}
+
+// rebuild lays out the month, weeks starting on Monday
+func (c *ContentCalendar) rebuild() {
+	c.monthLbl.SetText(c.month.Format("January 2006"))
+	now := time.Now()
+
+	byDay := make(map[int][]fyne.CanvasObject)
+	c.undated.Objects = nil
+	for _, p := range c.pages {
+		p := p
+		status := p.Status(now)
+		published, ok := p.PublishDate()
+		if !ok && status == site.StatusDraft {
+			c.undated.Add(c.pageButton(p, p.Title(), status))
+		}
+		if ok && sameMonth(published, c.month) {
+			byDay[published.Day()] = append(byDay[published.Day()], c.pageButton(p, p.Title(), status))
+		}
+		if expiry, ok := p.ExpiryDate(); ok && sameMonth(expiry, c.month) {
+			byDay[expiry.Day()] = append(byDay[expiry.Day()], c.pageButton(p, "Expires: "+p.Title(), status))
+		}
+	}
+	c.undated.Refresh()
+
+	var cells []fyne.CanvasObject
+	for _, day := range []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"} {
+		cells = append(cells, widget.NewLabelWithStyle(day, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}))
+	}
+	for i := 0; i < (int(c.month.Weekday())+6)%7; i++ {
+		cells = append(cells, widget.NewLabel(""))
+	}
+	days := c.month.AddDate(0, 1, -1).Day()
+	for day := 1; day <= days; day++ {
+		label := widget.NewLabel(fmt.Sprint(day))
+		if sameMonth(now, c.month) && now.Day() == day {
+			label.TextStyle = fyne.TextStyle{Bold: true}
+			label.SetText(fmt.Sprintf("%d · today", day))
+		}
+		cells = append(cells, container.NewVBox(append([]fyne.CanvasObject{label}, byDay[day]...)...))
+	}
+
+	c.grid.Objects = cells
+	c.grid.Refresh()
+}
+
+// pageButton shows a page in the calendar, colored by its status
+func (c *ContentCalendar) pageButton(p *site.Page, text, status string) *widget.Button {
+	if len([]rune(text)) > 28 {
+		text = string([]rune(text)[:28]) + "…"
+	}
+	btn := widget.NewButton(text, func() { c.showPageDialog(p) })
+	btn.Importance = statusImportance(status)
+	btn.Alignment = widget.ButtonAlignLeading
+	return btn
+}
+
+// showPageDialog shows the dates of a page and changes when it gets
+// published and when it expires
+func (c *ContentCalendar) showPageDialog(p *site.Page) {
+	publishEntry := dateEntry(c.window, p.PublishDate)
+	expiryEntry := dateEntry(c.window, p.ExpiryDate)
+
+	lastmod := "—"
+	if t, ok := p.Lastmod(); ok {
+		lastmod = cms.FormatDate(t)
+	}
+
+	openBtn := widget.NewButtonWithIcon("Open", theme.DocumentIcon(), func() {
+		if c.OnOpenFile != nil {
+			c.OnOpenFile(p.Path)
+		}
+	})
+	openBtn.Alignment = widget.ButtonAlignLeading
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Page", widget.NewLabel(p.Rel)),
+		widget.NewFormItem("Status", widget.NewLabel(p.Status(time.Now()))),
+		widget.NewFormItem("Publish on ("+p.PublishDateKey()+")", publishEntry),
+		widget.NewFormItem("Expires on", expiryEntry),
+		widget.NewFormItem("Last modified", widget.NewLabel(lastmod)),
+		widget.NewFormItem("", openBtn),
+	}
+	dialog.ShowForm(p.Title(), "Reschedule", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		changes := map[string]*widget.Entry{p.PublishDateKey(): publishEntry, p.ExpiryDateKey(): expiryEntry}
+		for key, entry := range changes {
+			orig, _ := p.Time(key)
+			if entry.Text == "" || (!orig.IsZero() && entry.Text == cms.FormatDate(orig)) {
+				continue
+			}
+			t, err := cms.ParseDate(entry.Text, time.Local)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("%s: %w", key, err), c.window)
+				return
+			}
+			if err := site.Reschedule(p.Path, key, t); err != nil {
+				dialog.ShowError(err, c.window)
+				return
+			}
+		}
+		c.refresh()
+	}, c.window)
+}
+
+// dateEntry edits a date of a page, with a date picker
+func dateEntry(w fyne.Window, get func() (time.Time, bool)) *widget.Entry {
+	entry := widget.NewEntry()
+	t, ok := get()
+	if ok {
+		entry.SetText(cms.FormatDate(t))
+	} else {
+		now := time.Now()
+		t = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	}
+	entry.SetPlaceHolder("Not set")
+	entry.Validator = func(text string) error {
+		if text == "" {
+			return nil
+		}
+		_, err := cms.ParseDate(text, t.Location())
+		return err
+	}
+	entry.ActionItem = widget.NewButtonWithIcon("", theme.CalendarIcon(), func() {
+		showDatePicker(w, entry, t)
+	})
+	return entry
+}
+
+func statusImportance(status string) widget.Importance {
+	switch status {
+	case site.StatusDraft:
+		return widget.WarningImportance
+	case site.StatusScheduled:
+		return widget.HighImportance
+	case site.StatusExpired:
+		return widget.DangerImportance
+	}
+	return widget.LowImportance
+}
+
+func sameMonth(t, month time.Time) bool {
+	return t.Year() == month.Year() && t.Month() == month.Month()
+}
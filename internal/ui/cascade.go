@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// effectiveView lists the parameters Hugo gives the page as edited, with
+// the page, section or site setting each one
+func (e *Editor) effectiveView() fyne.CanvasObject {
+	page := site.FindPage(e.pages, e.FullPath)
+	if page == nil {
+		return container.NewCenter(widget.NewLabel("Save the page to see what it inherits"))
+	}
+
+	// The fields as edited rather than as saved
+	edited := *page
+	edited.Meta = make(map[string]interface{}, len(e.widgetMap))
+	for k, field := range e.widgetMap {
+		if val, err := field.value(); err == nil {
+			edited.Meta[k] = val
+		}
+	}
+
+	rows := container.NewVBox(container.NewGridWithColumns(3,
+		widget.NewLabelWithStyle("Parameter", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Value", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Set by", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	))
+	for _, v := range e.Site.Effective(e.pages, &edited) {
+		value := widget.NewLabel(cms.FormatValue(v.Value))
+		value.Wrapping = fyne.TextWrapWord
+
+		origin := v.Origin
+		if v.Source != "" && v.Origin != site.OriginPage {
+			origin = fmt.Sprintf("%s (%s)", v.Origin, v.Source)
+		}
+		originLabel := widget.NewLabel(origin)
+		originLabel.Wrapping = fyne.TextWrapWord
+		if v.Origin != site.OriginPage {
+			originLabel.Importance = widget.LowImportance
+		}
+
+		rows.Add(container.NewGridWithColumns(3, widget.NewLabel(v.Key), value, originLabel))
+	}
+	return rows
+}
+
+// cascadeEditor edits the cascade of a section page: the values its
+// descendants get unless they set their own. A _target group narrows them
+// to some pages by path, kind, lang or environment.
+func (e *Editor) cascadeEditor() fyne.CanvasObject {
+	page := site.FindPage(e.pages, e.FullPath)
+
+	about := "Values here apply to every page below this section that doesn't set its own."
+	if page != nil {
+		n := len(e.Site.Descendants(e.pages, page))
+		about = fmt.Sprintf("Values here apply to the %d pages below this section that don't set their own.", n)
+	}
+	aboutLabel := widget.NewLabel(about)
+	aboutLabel.Wrapping = fyne.TextWrapWord
+
+	box := container.NewVBox(aboutLabel)
+	if field, ok := e.widgetMap["cascade"]; ok {
+		box.Add(field.widget)
+		return box
+	}
+
+	addBtn := widget.NewButtonWithIcon("Add cascade", theme.ContentAddIcon(), func() {
+		e.addField("cascade", map[string]interface{}{})
+		e.refreshForm()
+	})
+	addBtn.Alignment = widget.ButtonAlignLeading
+	box.Add(addBtn)
+	return box
+}
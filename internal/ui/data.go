@@ -0,0 +1,450 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// DataEditor lists the files of the site's data directory and edits them,
+// keeping their format. Lists of records are edited as tables.
+type DataEditor struct {
+	Site *site.SiteConfig
+
+	window    fyne.Window
+	container *fyne.Container
+	list      *widget.List
+	detail    *fyne.Container
+
+	files  []string // Relative to the data directory
+	path   string   // The file being edited
+	file   *cms.DataFile
+	keys   []string
+	fields map[string]*valueField
+	root   *valueField // The editor of a file that is a list
+}
+
+// NewDataEditor creates the data screen of a site
+func NewDataEditor(w fyne.Window, cfg *site.SiteConfig) *DataEditor {
+	d := &DataEditor{
+		Site:   cfg,
+		window: w,
+		detail: container.NewVBox(),
+	}
+
+	d.list = widget.NewList(
+		func() int { return len(d.files) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(d.files[id])
+		},
+	)
+	d.list.OnSelected = func(id widget.ListItemID) {
+		d.open(filepath.Join(d.Site.DataDir(), filepath.FromSlash(d.files[id])))
+	}
+
+	newBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), d.showNewFileDialog)
+	newBtn.Importance = widget.HighImportance
+	refreshBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), d.refresh)
+	topBar := container.NewBorder(nil, nil, nil, container.NewHBox(refreshBtn, newBtn),
+		widget.NewLabelWithStyle("Data files", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+
+	split := container.NewHSplit(
+		container.NewBorder(topBar, nil, nil, nil, d.list),
+		container.NewVScroll(d.detail),
+	)
+	split.Offset = 0.25
+
+	d.container = container.NewPadded(split)
+	d.refresh()
+	return d
+}
+
+// GetUI returns the container for this component
+func (d *DataEditor) GetUI() fyne.CanvasObject {
+	return d.container
+}
+
+// refresh lists the data files again
+func (d *DataEditor) refresh() {
+	files, err := d.Site.DataFiles()
+	if err != nil {
+		dialog.ShowError(err, d.window)
+	}
+	d.files = files
+	d.list.UnselectAll()
+	d.list.Refresh()
+
+	if len(files) == 0 {
+		d.detail.Objects = []fyne.CanvasObject{widget.NewLabel("The site has no data files yet")}
+		d.detail.Refresh()
+	}
+}
+
+// open shows the editor of a data file
+func (d *DataEditor) open(path string) {
+	file, err := site.ReadData(path)
+	if err != nil {
+		dialog.ShowError(err, d.window)
+		return
+	}
+	d.path = path
+	d.file = file
+	d.keys = nil
+	d.fields = make(map[string]*valueField)
+	d.root = nil
+
+	if file.Data == nil && file.List != nil {
+		d.root = newDataField(d.window, file.List)
+	} else {
+		for _, k := range file.Keys() {
+			d.keys = append(d.keys, k)
+			d.fields[k] = newDataField(d.window, file.Data[k])
+		}
+	}
+	d.rebuild()
+}
+
+// rebuild lays out the fields of the open file
+func (d *DataEditor) rebuild() {
+	rel, _ := filepath.Rel(d.Site.DataDir(), d.path)
+	saveBtn := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), d.save)
+	saveBtn.Importance = widget.HighImportance
+	header := container.NewBorder(nil, nil, nil, saveBtn,
+		widget.NewLabelWithStyle(filepath.ToSlash(rel)+"  ·  "+d.file.Format, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+
+	if d.root != nil {
+		d.detail.Objects = []fyne.CanvasObject{header, d.root.widget}
+		d.detail.Refresh()
+		return
+	}
+
+	form := widget.NewForm()
+	for _, k := range d.keys {
+		k := k
+		removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			delete(d.fields, k)
+			d.keys = removeString(d.keys, k)
+			d.rebuild()
+		})
+		removeBtn.Importance = widget.LowImportance
+		form.Append(k, container.NewBorder(nil, nil, nil, removeBtn, d.fields[k].widget))
+	}
+
+	addBtn := widget.NewButtonWithIcon("Add field", theme.ContentAddIcon(), func() {
+		showAddFieldDialog(d.window, func(k string, v interface{}) {
+			if _, exists := d.fields[k]; exists {
+				dialog.ShowError(fmt.Errorf("%s already exists", k), d.window)
+				return
+			}
+			d.keys = append(d.keys, k)
+			d.fields[k] = newDataField(d.window, v)
+			d.rebuild()
+		})
+	})
+	addBtn.Importance = widget.LowImportance
+	addBtn.Alignment = widget.ButtonAlignLeading
+
+	d.detail.Objects = []fyne.CanvasObject{header, form, addBtn}
+	d.detail.Refresh()
+}
+
+// save writes the file back, untouched values as they were
+func (d *DataEditor) save() {
+	if d.root != nil {
+		val, err := d.root.value()
+		if err != nil {
+			dialog.ShowError(err, d.window)
+			return
+		}
+		d.file.List, _ = val.([]interface{})
+	} else {
+		data := make(map[string]interface{}, len(d.keys))
+		for _, k := range d.keys {
+			val, err := d.fields[k].value()
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("%s: %w", k, err), d.window)
+				return
+			}
+			data[k] = val
+		}
+		d.file.Data = data
+	}
+
+	if err := site.WriteData(d.path, d.file); err != nil {
+		dialog.ShowError(err, d.window)
+	}
+}
+
+// showNewFileDialog creates an empty data file
+func (d *DataEditor) showNewFileDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("e.g. team or products/featured")
+	formatSelect := widget.NewSelect([]string{"yaml", "toml", "json"}, nil)
+	formatSelect.SetSelected("yaml")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Format", formatSelect),
+	}
+	dialog.ShowForm("New Data File", "Create", "Cancel", items, func(ok bool) {
+		name := strings.Trim(strings.TrimSpace(nameEntry.Text), "/")
+		if !ok || name == "" {
+			return
+		}
+		path := filepath.Join(d.Site.DataDir(), filepath.FromSlash(name)+"."+formatSelect.Selected)
+		if _, err := os.Stat(path); err == nil {
+			dialog.ShowError(fmt.Errorf("%s already exists", name), d.window)
+			return
+		}
+		content := ""
+		if formatSelect.Selected == "json" {
+			content = "{}\n"
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			dialog.ShowError(err, d.window)
+			return
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			dialog.ShowError(err, d.window)
+			return
+		}
+		d.refresh()
+		d.open(path)
+	}, d.window)
+}
+
+// newDataField is newValueField with lists of records shown as tables
+func newDataField(w fyne.Window, val interface{}) *valueField {
+	switch v := val.(type) {
+	case []interface{}:
+		if isObjectList(v) {
+			return newTableField(w, v, false)
+		}
+	case []map[string]interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return newTableField(w, items, true)
+	}
+	return newValueField(w, val)
+}
+
+// tableRow is a record of a table field, with a cell for each column
+type tableRow struct {
+	cells map[string]*valueField
+	set   map[string]bool // The columns the record had, which are kept even when emptied
+}
+
+// newTableField edits a list of records as a table, a column for each key.
+// Nested values are edited in a dialog. typed is set for
+// []map[string]interface{} so the list is handed back with the same type.
+func newTableField(w fyne.Window, list []interface{}, typed bool) *valueField {
+	var columns []string
+	seen := make(map[string]bool)
+	var rows []*tableRow
+	for _, item := range list {
+		m, _ := item.(map[string]interface{})
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		row := &tableRow{cells: make(map[string]*valueField), set: make(map[string]bool)}
+		for _, k := range keys {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+			row.cells[k] = newCellField(w, m[k])
+			row.set[k] = true
+		}
+		rows = append(rows, row)
+	}
+
+	// New records and cells start empty, with the types of the first one
+	template := map[string]interface{}{}
+	if len(list) > 0 {
+		if first, ok := list[0].(map[string]interface{}); ok {
+			template = emptyLike(first).(map[string]interface{})
+		}
+	}
+	cell := func(row *tableRow, k string) *valueField {
+		if _, ok := row.cells[k]; !ok {
+			empty, ok := template[k]
+			if !ok {
+				empty = ""
+			}
+			row.cells[k] = newCellField(w, empty)
+		}
+		return row.cells[k]
+	}
+
+	box := container.NewVBox()
+	var rebuild func()
+	rebuild = func() {
+		grid := container.NewGridWithColumns(len(columns) + 1)
+		for _, k := range columns {
+			grid.Add(widget.NewLabelWithStyle(k, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		}
+		grid.Add(widget.NewLabel(""))
+
+		for i, row := range rows {
+			i := i
+			for _, k := range columns {
+				grid.Add(cell(row, k).widget)
+			}
+
+			upBtn := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+				rows[i-1], rows[i] = rows[i], rows[i-1]
+				rebuild()
+			})
+			removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				rows = append(rows[:i], rows[i+1:]...)
+				rebuild()
+			})
+			if i == 0 {
+				upBtn.Disable()
+			}
+			upBtn.Importance = widget.LowImportance
+			removeBtn.Importance = widget.LowImportance
+			grid.Add(container.NewHBox(upBtn, removeBtn))
+		}
+
+		addRowBtn := widget.NewButtonWithIcon("Add row", theme.ContentAddIcon(), func() {
+			rows = append(rows, &tableRow{cells: make(map[string]*valueField), set: make(map[string]bool)})
+			rebuild()
+		})
+		addColumnBtn := widget.NewButtonWithIcon("Add column", theme.ContentAddIcon(), func() {
+			showAddFieldDialog(w, func(k string, v interface{}) {
+				if seen[k] {
+					dialog.ShowError(fmt.Errorf("%s already exists", k), w)
+					return
+				}
+				seen[k] = true
+				columns = append(columns, k)
+				template[k] = v
+				rebuild()
+			})
+		})
+		addRowBtn.Importance = widget.LowImportance
+		addColumnBtn.Importance = widget.LowImportance
+
+		box.Objects = []fyne.CanvasObject{
+			container.NewHScroll(grid),
+			container.NewHBox(addRowBtn, addColumnBtn),
+		}
+		box.Refresh()
+	}
+	rebuild()
+
+	return &valueField{
+		widget: box,
+		value: func() (interface{}, error) {
+			out := make([]interface{}, len(rows))
+			typedOut := make([]map[string]interface{}, len(rows))
+			for i, row := range rows {
+				m := make(map[string]interface{})
+				for _, k := range columns {
+					c, ok := row.cells[k]
+					if !ok {
+						continue
+					}
+					v, err := c.value()
+					if err != nil {
+						return nil, fmt.Errorf("row %d, %s: %w", i+1, k, err)
+					}
+					if !row.set[k] && isEmptyValue(v) {
+						continue // Left empty in a record that didn't have it
+					}
+					m[k] = v
+				}
+				out[i] = m
+				typedOut[i] = m
+			}
+			if typed {
+				return typedOut, nil
+			}
+			return out, nil
+		},
+	}
+}
+
+// newCellField edits a value in a table cell: text in place, nested maps
+// and lists in a dialog
+func newCellField(w fyne.Window, val interface{}) *valueField {
+	switch val.(type) {
+	case map[string]interface{}, []map[string]interface{}:
+	case []interface{}:
+		if !isObjectList(val.([]interface{})) {
+			return newValueField(w, val)
+		}
+	default:
+		return newValueField(w, val)
+	}
+
+	current := val
+	var btn *widget.Button
+	btn = widget.NewButtonWithIcon(cellSummary(current), theme.DocumentCreateIcon(), func() {
+		field := newDataField(w, current)
+		d := dialog.NewCustomConfirm("Edit", "Done", "Cancel", container.NewVScroll(field.widget), func(ok bool) {
+			if !ok {
+				return
+			}
+			v, err := field.value()
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			current = v
+			btn.SetText(cellSummary(current))
+		}, w)
+		d.Resize(fyne.NewSize(600, 450))
+		d.Show()
+	})
+	btn.Alignment = widget.ButtonAlignLeading
+	return &valueField{
+		widget: btn,
+		value:  func() (interface{}, error) { return current, nil },
+	}
+}
+
+// cellSummary describes a nested value in a table cell
+func cellSummary(val interface{}) string {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return fmt.Sprintf("%d fields", len(v))
+	case []interface{}:
+		return fmt.Sprintf("%d items", len(v))
+	case []map[string]interface{}:
+		return fmt.Sprintf("%d items", len(v))
+	}
+	return cms.FormatValue(val)
+}
+
+func isEmptyValue(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
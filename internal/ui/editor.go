@@ -5,19 +5,22 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
 	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+	"github.com/GopherGhaznix/Bayan/internal/site"
 )
 
 type Editor struct {
+	Site     *site.SiteConfig // The Hugo site the file belongs to
 	FullPath string
 	OnClose  func()
 
@@ -25,73 +28,55 @@ type Editor struct {
 	mdFile    *cms.MDFile
 	container *fyne.Container // The main UI container
 
-	// Map to hold references to UI widgets for each key
-	// Key -> widget
-	widgetMap map[string]fyne.CanvasObject
-	fieldMap  map[string]interface{} // Reference to original value (for type checking)
+	// Map to hold references to the field editors for each key
+	// Key -> field
+	widgetMap map[string]*valueField
+	keys      []string // The fields in the order they are shown
+
+	metadata *fyne.Container // Holds the metadata form
+	cascade  *fyne.Container // Holds the cascade editor of a section, or nil
+	main     *fyne.Container // Holds the tabs, or the side by side view
+	tabs     *container.AppTabs
 
 	// Body Content
 	bodyEntry *widget.Entry
+
+	permalink *widget.Label // The URL the page is published at
+
+	problems map[string][]string // Front matter rules broken, by key
+
+	pages      []*site.Page // Content of the site, for translations and terms
+	taxonomies []*site.Taxonomy
 }
 
-func NewEditor(w fyne.Window, path string, onClose func()) *Editor {
+func NewEditor(w fyne.Window, cfg *site.SiteConfig, path string, onClose func()) *Editor {
 	e := &Editor{
+		Site:      cfg,
 		FullPath:  path,
 		OnClose:   onClose,
 		window:    w,
-		widgetMap: make(map[string]fyne.CanvasObject),
-		fieldMap:  make(map[string]interface{}),
+		widgetMap: make(map[string]*valueField),
 	}
 
 	e.load()
 
-	// Metadata Form Generation
-	form := widget.NewForm()
+	// Terms already used on the site, to suggest them in taxonomy fields
+	pages, err := cfg.Pages()
+	if err != nil {
+		fyne.LogError("Failed to read content", err)
+	}
+	e.pages = pages
+	e.taxonomies = cfg.TaxonomyIndex(pages)
 
-	// Sort keys for deterministic order
-	keys := make([]string, 0, len(e.mdFile.MetaData))
-	for k := range e.mdFile.MetaData {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	for _, k := range keys {
-		val := e.mdFile.MetaData[k]
-		e.fieldMap[k] = val // Store original val for later type check if needed
-
-		// Create widget based on type
-		switch v := val.(type) {
-		case bool:
-			check := widget.NewCheck("", nil)
-			check.Checked = v
-			e.widgetMap[k] = check
-			form.Append(k, check)
-
-		case []interface{}: // YAML often decodes lists as []interface{}
-			// Comma separated string
-			strs := make([]string, len(v))
-			for i, item := range v {
-				strs[i] = fmt.Sprintf("%v", item)
-			}
-			entry := widget.NewEntry()
-			entry.SetText(strings.Join(strs, ", "))
-			e.widgetMap[k] = entry
-			form.Append(k, entry)
-
-		case []string:
-			entry := widget.NewEntry()
-			entry.SetText(strings.Join(v, ", "))
-			e.widgetMap[k] = entry
-			form.Append(k, entry)
-
-		default:
-			// Treat as string
-			entry := widget.NewEntry()
-			entry.SetText(fmt.Sprintf("%v", v))
-			e.widgetMap[k] = entry
-			form.Append(k, entry)
-		}
+	// Metadata Form Generation, keeping the order of the file
+	for _, k := range e.mdFile.Keys() {
+		e.addField(k, e.mdFile.MetaData[k])
+	}
+	e.metadata = container.NewVBox()
+	if _, branch := cms.IsBundleIndex(path); branch {
+		e.cascade = container.NewVBox()
 	}
+	e.refreshForm()
 
 	// Add a "New Field" button? Maybe later.
 
@@ -101,6 +86,10 @@ func NewEditor(w fyne.Window, path string, onClose func()) *Editor {
 	e.bodyEntry.TextStyle = fyne.TextStyle{Monospace: true}
 	e.bodyEntry.Wrapping = fyne.TextWrapWord
 
+	shortcodeBtn := widget.NewButtonWithIcon("Shortcode", theme.ContentAddIcon(), e.showShortcodePalette)
+	shortcodeBtn.Importance = widget.LowImportance
+	contentBar := container.NewHBox(shortcodeBtn)
+
 	// Preview Content
 	preview := widget.NewRichTextFromMarkdown(e.bodyEntry.Text)
 	preview.Wrapping = fyne.TextWrapWord
@@ -111,7 +100,7 @@ func NewEditor(w fyne.Window, path string, onClose func()) *Editor {
 			e.OnClose()
 		}
 	})
-	saveBtn := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), e.save)
+	saveBtn := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() { e.save() })
 	saveBtn.Importance = widget.HighImportance
 
 	label := widget.NewLabel(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
@@ -120,29 +109,108 @@ func NewEditor(w fyne.Window, path string, onClose func()) *Editor {
 	}
 
 	label.Wrapping = fyne.TextWrapBreak
-	topBar := container.NewBorder(nil, nil, closeBtn, saveBtn, label)
+	actions := container.NewHBox(saveBtn)
+	if langSelect := e.languageSelect(); langSelect != nil {
+		actions.Objects = append([]fyne.CanvasObject{langSelect}, actions.Objects...)
+	}
+	if sourceSelect := e.sourceSelect(); sourceSelect != nil {
+		actions.Objects = append([]fyne.CanvasObject{sourceSelect}, actions.Objects...)
+	}
+	topBar := container.NewVBox(
+		container.NewBorder(nil, nil, closeBtn, actions, label),
+		e.permalinkBar(),
+	)
 
 	// Layout with Tabs
 	tabs := container.NewAppTabs(
-		container.NewTabItem("Metadata", container.NewVScroll(form)),
-		container.NewTabItem("Content", container.NewBorder(nil, nil, nil, nil, e.bodyEntry)),
+		container.NewTabItem("Metadata", container.NewVScroll(e.metadata)),
+		container.NewTabItem("Content", container.NewBorder(contentBar, nil, nil, nil, e.bodyEntry)),
 		container.NewTabItem("Preview", container.NewVScroll(preview)),
 	)
 
-	// On tab change, update preview
+	if isBundle, _ := cms.IsBundleIndex(path); isBundle {
+		tabs.Append(container.NewTabItem("Resources", container.NewVScroll(e.resourceList())))
+	}
+	if e.cascade != nil {
+		tabs.Append(container.NewTabItem("Cascade", container.NewVScroll(e.cascade)))
+	}
+	effective := container.NewVBox()
+	tabs.Append(container.NewTabItem("Effective", container.NewVScroll(effective)))
+	stats := container.NewVBox()
+	tabs.Append(container.NewTabItem("Stats", container.NewVScroll(stats)))
+	related := container.NewVBox()
+	var refreshRelated func()
+	refreshRelated = func() {
+		related.Objects = []fyne.CanvasObject{e.relatedView(refreshRelated)}
+		related.Refresh()
+	}
+	tabs.Append(container.NewTabItem("Related", container.NewVScroll(related)))
+
+	// On tab change, update the views of the page as edited
 	tabs.OnSelected = func(i *container.TabItem) {
-		if i.Text == "Preview" {
+		switch i.Text {
+		case "Preview":
 			preview.ParseMarkdown(e.bodyEntry.Text)
+		case "Effective":
+			effective.Objects = []fyne.CanvasObject{e.effectiveView()}
+			effective.Refresh()
+		case "Stats":
+			stats.Objects = []fyne.CanvasObject{e.statsView()}
+			stats.Refresh()
+		case "Related":
+			refreshRelated()
 		}
 	}
 
+	e.tabs = tabs
+	e.main = container.NewStack(tabs)
 	e.container = container.NewPadded(
-		container.NewBorder(topBar, nil, nil, nil, tabs),
+		container.NewBorder(topBar, nil, nil, nil, e.main),
 	)
 
 	return e
 }
 
+// addField creates the editor of a front matter value. Nested maps and
+// lists get their own forms, taxonomies suggest the terms in use.
+func (e *Editor) addField(k string, val interface{}) {
+	var field *valueField
+	if t := site.FindTaxonomy(e.taxonomies, k); t != nil && isTermsValue(val) {
+		field = newTermsField(val, t.Names())
+	} else {
+		field = newValueField(e.window, val)
+	}
+	if _, exists := e.widgetMap[k]; !exists {
+		e.keys = append(e.keys, k)
+	}
+	e.widgetMap[k] = field
+}
+
+// refreshForm lays the fields out in the metadata form. The cascade of a
+// section has a tab of its own.
+func (e *Editor) refreshForm() {
+	form := widget.NewForm()
+	for _, k := range e.keys {
+		if k == "cascade" && e.cascade != nil {
+			continue
+		}
+		item := widget.NewFormItem(k, e.widgetMap[k].widget)
+		for key, msgs := range e.problems {
+			if strings.EqualFold(key, k) {
+				item.HintText = strings.Join(msgs, ", ")
+			}
+		}
+		form.AppendItem(item)
+	}
+	e.metadata.Objects = []fyne.CanvasObject{e.problemsView(), form}
+	e.metadata.Refresh()
+
+	if e.cascade != nil {
+		e.cascade.Objects = []fyne.CanvasObject{e.cascadeEditor()}
+		e.cascade.Refresh()
+	}
+}
+
 func (e *Editor) GetUI() fyne.CanvasObject {
 	return e.container
 }
@@ -168,49 +236,245 @@ func (e *Editor) load() {
 	}
 }
 
-func (e *Editor) save() {
-	// Update struct from UI
-	for k, widgetObj := range e.widgetMap {
-		switch w := widgetObj.(type) {
-		case *widget.Check:
-			e.mdFile.MetaData[k] = w.Checked
-		case *widget.Entry:
-			// Check if it was a list
-			origVal, exists := e.fieldMap[k]
-			isList := false
-			if exists {
-				switch origVal.(type) {
-				case []interface{}, []string:
-					isList = true
-				}
-			}
+// save writes the page, reporting whether it could
+func (e *Editor) save() bool {
+	// Convert every field before touching the file, so a bad value doesn't
+	// leave it half updated
+	values := make(map[string]interface{}, len(e.widgetMap))
+	for k, field := range e.widgetMap {
+		val, err := field.value()
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s: %w", k, err), e.window)
+			return false
+		}
+		values[k] = val
+	}
 
-			if isList {
-				parts := strings.Split(w.Text, ",")
-				cleanParts := make([]string, 0, len(parts))
-				for _, p := range parts {
-					cleanParts = append(cleanParts, strings.TrimSpace(p))
-				}
-				e.mdFile.MetaData[k] = cleanParts
-			} else {
-				e.mdFile.MetaData[k] = w.Text
+	// The page as it is about to be written
+	page := site.FindPage(e.pages, e.FullPath)
+	edited := site.Page{Path: e.FullPath}
+	if page != nil {
+		edited = *page
+	}
+	edited.Body = e.bodyEntry.Text
+	edited.Meta = make(map[string]interface{}, len(e.mdFile.MetaData))
+	for k, val := range e.mdFile.MetaData {
+		edited.Meta[k] = val
+	}
+	for k, val := range values {
+		edited.Meta[k] = val
+	}
+
+	// Pages must follow the front matter rules of the site. Drafts are
+	// written anyway, with the problems shown.
+	if !e.validate(&edited) && edited.Status(time.Now()) != site.StatusDraft {
+		dialog.ShowError(fmt.Errorf("not saved: the front matter breaks the rules of the site, see the fields"), e.window)
+		return false
+	}
+
+	// A page whose URL changes keeps the old one as an alias, so links to
+	// it keep working
+	alias := ""
+	if page != nil {
+		old := e.Site.RelPermalink(page)
+		if page.Status(time.Now()) != site.StatusDraft && old != e.Site.RelPermalink(&edited) {
+			if alias = site.AppendAlias(edited.Meta, old); alias != "" {
+				values[alias] = edited.Meta[alias]
 			}
 		}
 	}
 
+	// Update struct from UI, going back to what was last saved if the file
+	// can't be written
+	meta, body := e.mdFile.MetaData, e.mdFile.Body
+	e.mdFile.MetaData = make(map[string]interface{}, len(meta)+len(values))
+	for k, val := range meta {
+		e.mdFile.MetaData[k] = val
+	}
+	for k, val := range values {
+		e.mdFile.MetaData[k] = val
+	}
+
 	e.mdFile.Body = e.bodyEntry.Text
 
 	data, err := e.mdFile.ToString()
+	if err == nil {
+		err = os.WriteFile(e.FullPath, []byte(data), 0644)
+	}
 	if err != nil {
+		e.mdFile.MetaData, e.mdFile.Body = meta, body
 		dialog.ShowError(err, e.window)
-		return
+		return false
+	}
+	if page != nil {
+		page.Meta = edited.Meta
 	}
+	if alias != "" {
+		e.addField(alias, edited.Meta[alias])
+		e.refreshForm()
+	}
+	e.Site.Forget(e.FullPath)
+
+	log.Println("File saved successfully")
+	e.checkLinks()
+	e.refreshPermalink()
+	return true
+}
 
-	err = os.WriteFile(e.FullPath, []byte(data), 0644)
+// checkLinks shows the links of the saved page that lead nowhere, so a
+// rename doesn't go unnoticed until Hugo fails to build. Links are checked
+// against the content as it is now, with the pages added or moved since
+// the editor opened.
+func (e *Editor) checkLinks() {
+	pages, err := e.Site.Pages()
 	if err != nil {
-		dialog.ShowError(err, e.window)
+		return
+	}
+	e.pages = pages
+	page := site.FindPage(pages, e.FullPath)
+	if page == nil {
 		return
 	}
 
-	log.Println("File saved successfully")
+	saved := *page
+	saved.Meta = e.mdFile.MetaData
+	saved.Body = e.mdFile.Body
+	if broken := e.Site.CheckPage(e.pages, &saved); len(broken) > 0 {
+		showBrokenLinks(e.window, "Saved with broken links", broken, e.open)
+	}
+}
+
+// languageSelect switches to the other language versions of the page. It is
+// nil for sites with a single language.
+func (e *Editor) languageSelect() *widget.Select {
+	page := site.FindPage(e.pages, e.FullPath)
+	if !e.Site.Multilingual() || page == nil {
+		return nil
+	}
+
+	codes := make([]string, len(e.Site.Languages))
+	for i, lang := range e.Site.Languages {
+		codes[i] = lang.Code
+	}
+
+	langSelect := widget.NewSelect(codes, nil)
+	langSelect.Selected = page.Lang
+	langSelect.OnChanged = func(code string) {
+		if code == page.Lang {
+			return
+		}
+		langSelect.SetSelected(page.Lang) // Stay here until the other one opens
+
+		if t, ok := site.Translations(e.pages, page)[code]; ok {
+			e.open(t.Path)
+			return
+		}
+		message := fmt.Sprintf("There is no %s version of this page yet. Create it from a copy of its front matter?", code)
+		dialog.ShowConfirm("Translate", message, func(ok bool) {
+			if !ok {
+				return
+			}
+			path, err := e.Site.Translate(page, code)
+			if err != nil {
+				dialog.ShowError(err, e.window)
+				return
+			}
+			e.open(path)
+		}, e.window)
+	}
+	return langSelect
+}
+
+// permalinkBar shows the URL of the page with a button to copy it
+func (e *Editor) permalinkBar() fyne.CanvasObject {
+	e.permalink = widget.NewLabel("")
+	e.permalink.Truncation = fyne.TextTruncateEllipsis
+	e.permalink.Importance = widget.LowImportance
+	e.refreshPermalink()
+
+	copyBtn := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+		if page := site.FindPage(e.pages, e.FullPath); page != nil {
+			fyne.CurrentApp().Clipboard().SetContent(e.permalink.Text)
+		}
+	})
+	copyBtn.Importance = widget.LowImportance
+	slugBtn := widget.NewButton("Slug", e.showSlugDialog)
+	slugBtn.Importance = widget.LowImportance
+	return container.NewBorder(nil, nil, nil, container.NewHBox(slugBtn, copyBtn), e.permalink)
+}
+
+// refreshPermalink computes the URL of the page as last saved
+func (e *Editor) refreshPermalink() {
+	page := site.FindPage(e.pages, e.FullPath)
+	if page == nil {
+		e.permalink.SetText("Save the page to see its URL")
+		return
+	}
+	saved := *page
+	saved.Meta = e.mdFile.MetaData
+	e.permalink.SetText(e.Site.Permalink(&saved))
+}
+
+// open replaces the editor with one for another file
+func (e *Editor) open(path string) {
+	editor := NewEditor(e.window, e.Site, path, e.OnClose)
+	e.window.SetContent(editor.GetUI())
+}
+
+// resourceList lists the files of the page bundle, with the title and name
+// the resources front matter gives them
+func (e *Editor) resourceList() fyne.CanvasObject {
+	names := cms.BundleResources(e.FullPath)
+	if len(names) == 0 {
+		return container.NewCenter(widget.NewLabel("This bundle has no resources yet"))
+	}
+
+	list := container.NewVBox()
+	for _, name := range names {
+		path := filepath.Join(filepath.Dir(e.FullPath), filepath.FromSlash(name))
+
+		var icon fyne.CanvasObject = widget.NewIcon(theme.FileIcon())
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg":
+			img := canvas.NewImageFromFile(path)
+			img.FillMode = canvas.ImageFillContain
+			img.SetMinSize(fyne.NewSize(64, 64))
+			icon = img
+		}
+
+		details := widget.NewLabel("")
+		if meta := cms.ResourceMeta(e.mdFile.MetaData, name); meta != nil {
+			var parts []string
+			for _, k := range []string{"title", "name"} {
+				if v, ok := meta[k]; ok {
+					parts = append(parts, k+": "+cms.FormatValue(v))
+				}
+			}
+			details.SetText(strings.Join(parts, "  ·  "))
+		}
+
+		size := ""
+		if info, err := os.Stat(path); err == nil {
+			size = formatSize(info.Size())
+		}
+
+		list.Add(container.NewBorder(nil, nil, icon, widget.NewLabel(size),
+			container.NewVBox(
+				widget.NewLabelWithStyle(name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				details,
+			),
+		))
+	}
+	return list
+}
+
+// formatSize returns a file size for people, e.g. 1.2 MB
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
 }
@@ -1,22 +1,28 @@
 package ui
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+	"github.com/GopherGhaznix/Bayan/internal/site"
 	"github.com/GopherGhaznix/Bayan/resources"
 )
 
 // FileExplorer is a UI component for navigating files
 type FileExplorer struct {
-	RootPath    string // The starting root path
+	Site        *site.SiteConfig // The Hugo site the content belongs to
+	RootPath    string           // The starting root path
 	CurrentPath string
 	OnOpenFile  func(string) // Callback when a file is selected
 	OnExit      func()       // Callback to exit explorer
@@ -24,15 +30,25 @@ type FileExplorer struct {
 	window    fyne.Window // Reference to window for dialogs
 	container *fyne.Container
 	list      *widget.List
-	files     []os.DirEntry
+	files     []explorerItem
+	pages     []*site.Page // Content of the site, to find translations
 	pathLabel *widget.Label
 	upBtn     *widget.Button // Reference to update visibility
 
 }
 
-// NewFileExplorer creates a new file explorer starting at root path
-func NewFileExplorer(w fyne.Window, root string, onOpenFile func(string), onExit func()) *FileExplorer {
+// explorerItem is a row of the explorer: a folder, a page, or a page
+// bundle shown as the page it holds
+type explorerItem struct {
+	entry   os.DirEntry
+	index   string // The content file of a leaf bundle
+	section bool   // A folder with an _index.md, or that _index.md itself
+}
+
+// NewFileExplorer creates a new file explorer for a site starting at root path
+func NewFileExplorer(w fyne.Window, cfg *site.SiteConfig, root string, onOpenFile func(string), onExit func()) *FileExplorer {
 	e := &FileExplorer{
+		Site:        cfg,
 		RootPath:    root,
 		CurrentPath: root,
 		OnOpenFile:  onOpenFile,
@@ -48,19 +64,53 @@ func NewFileExplorer(w fyne.Window, root string, onOpenFile func(string), onExit
 			return len(e.files)
 		},
 		func() fyne.CanvasObject {
-			return widget.NewButtonWithIcon("", theme.FolderIcon(), nil)
+			return container.NewBorder(nil, nil, nil, container.NewHBox(),
+				widget.NewButtonWithIcon("", theme.FolderIcon(), nil))
 		},
 		func(id widget.ListItemID, o fyne.CanvasObject) {
-			btn := o.(*widget.Button)
+			row := o.(*fyne.Container)
+			btn := row.Objects[0].(*widget.Button)
+			langs := row.Objects[1].(*fyne.Container)
+			langs.Objects = nil
+
 			btn.Importance = widget.LowImportance
-			entry := e.files[id]
-			if entry.IsDir() {
+			item := e.files[id]
+			entry := item.entry
+			switch {
+			case item.index != "":
+				btn.SetIcon(resources.WebpageIcon())
+				btn.SetText(entry.Name())
+				if e.Site.Multilingual() {
+					langs.Objects = e.translationButtons(item.index)
+				}
+			case entry.IsDir() && item.section:
+				btn.SetIcon(theme.FolderIcon())
+				btn.SetText(entry.Name() + "  ·  section")
+			case entry.IsDir():
 				btn.SetIcon(theme.FolderIcon())
 				btn.SetText(entry.Name())
-			} else {
+			case item.section:
+				btn.SetIcon(theme.HomeIcon())
+				btn.SetText("Section page")
+				if e.Site.Multilingual() {
+					langs.Objects = e.translationButtons(filepath.Join(e.CurrentPath, entry.Name()))
+				}
+			default:
+				_, base := e.Site.SplitLang(entry.Name())
 				btn.SetIcon(theme.DocumentIcon())
-				btn.SetText(strings.TrimRight(entry.Name(), ".md"))
+				btn.SetText(strings.TrimSuffix(base, filepath.Ext(base)))
+				if e.Site.Multilingual() {
+					langs.Objects = e.translationButtons(filepath.Join(e.CurrentPath, entry.Name()))
+				}
+			}
+			if !item.section || entry.IsDir() {
+				moveBtn := widget.NewButtonWithIcon("", theme.MoreHorizontalIcon(), func() {
+					e.showMoveDialog(item)
+				})
+				moveBtn.Importance = widget.LowImportance
+				langs.Objects = append(langs.Objects, moveBtn)
 			}
+			langs.Refresh()
 			btn.OnTapped = func() {
 				e.onItemTapped(id)
 			}
@@ -84,9 +134,38 @@ func NewFileExplorer(w fyne.Window, root string, onOpenFile func(string), onExit
 	newFolderBtn := widget.NewButtonWithIcon("", theme.FolderNewIcon(), e.showNewFolderDialog)
 	newFolderBtn.Importance = widget.HighImportance
 
-	newFileBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), e.showNewFileDialog)
+	newBundleBtn := widget.NewButtonWithIcon("", resources.WebpageIcon(), func() {
+		e.showNewFileDialog(true)
+	})
+	newBundleBtn.Importance = widget.HighImportance
+
+	newFileBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+		e.showNewFileDialog(false)
+	})
 	newFileBtn.Importance = widget.HighImportance
 
+	checkLinksBtn := widget.NewButtonWithIcon("", theme.SearchIcon(), e.checkLinks)
+	aliasesBtn := widget.NewButtonWithIcon("", theme.WarningIcon(), e.checkAliases)
+	rulesBtn := widget.NewButtonWithIcon("", theme.ConfirmIcon(), e.checkRules)
+
+	// Screens reading the whole content load it when first shown
+	search := NewContentSearch(w, cfg, onOpenFile)
+	searchTab := container.NewTabItemWithIcon("Search", theme.SearchIcon(), search.GetUI())
+	calendar := NewContentCalendar(w, cfg, onOpenFile)
+	calendarTab := container.NewTabItemWithIcon("Calendar", theme.CalendarIcon(), calendar.GetUI())
+	taxonomies := NewTaxonomyBrowser(w, cfg)
+	taxonomiesTab := container.NewTabItemWithIcon("Taxonomies", theme.ListIcon(), taxonomies.GetUI())
+	menus := NewMenuEditor(w, cfg)
+	menusTab := container.NewTabItemWithIcon("Menus", theme.MenuIcon(), menus.GetUI())
+
+	// Editors reading files of their own are made when first shown
+	lazy := make(map[*container.TabItem]func() fyne.CanvasObject)
+	lazyTab := func(text string, icon fyne.Resource, build func() fyne.CanvasObject) *container.TabItem {
+		tab := container.NewTabItemWithIcon(text, icon, container.NewStack())
+		lazy[tab] = build
+		return tab
+	}
+
 	apptabs := container.NewAppTabs(
 		container.NewTabItemWithIcon(
 			"Content",
@@ -96,7 +175,7 @@ func NewFileExplorer(w fyne.Window, root string, onOpenFile func(string), onExit
 					container.NewBorder(
 						nil, nil,
 						container.NewHBox(homeBtn, e.upBtn),
-						container.NewHBox(newFolderBtn, newFileBtn),
+						container.NewHBox(checkLinksBtn, aliasesBtn, rulesBtn, newFolderBtn, newBundleBtn, newFileBtn),
 						e.pathLabel,
 					),
 					nil,
@@ -106,6 +185,7 @@ func NewFileExplorer(w fyne.Window, root string, onOpenFile func(string), onExit
 				),
 			),
 		),
+		searchTab,
 		container.NewTabItemWithIcon(
 			"Themes",
 			theme.ColorPaletteIcon(),
@@ -113,6 +193,18 @@ func NewFileExplorer(w fyne.Window, root string, onOpenFile func(string), onExit
 				widget.NewLabel("comming soon!"),
 			),
 		),
+		calendarTab,
+		lazyTab("Data", theme.StorageIcon(), func() fyne.CanvasObject {
+			return NewDataEditor(w, cfg).GetUI()
+		}),
+		taxonomiesTab,
+		lazyTab("Strings", theme.FileTextIcon(), func() fyne.CanvasObject {
+			return NewI18nEditor(w, cfg).GetUI()
+		}),
+		menusTab,
+		lazyTab("Settings", theme.SettingsIcon(), func() fyne.CanvasObject {
+			return NewSettings(w, cfg).GetUI()
+		}),
 		container.NewTabItemWithIcon(
 			"Git Changes",
 			theme.HistoryIcon(),
@@ -122,6 +214,26 @@ func NewFileExplorer(w fyne.Window, root string, onOpenFile func(string), onExit
 		),
 	)
 
+	// The content may have changed since the tab was last shown
+	apptabs.OnSelected = func(tab *container.TabItem) {
+		if build, ok := lazy[tab]; ok {
+			delete(lazy, tab)
+			tab.Content.(*fyne.Container).Add(build())
+		}
+		switch tab {
+		case searchTab:
+			search.refresh()
+		case calendarTab:
+			calendar.refresh()
+		case taxonomiesTab:
+			taxonomies.refresh()
+		case menusTab:
+			if menus.menu == nil { // Edits not saved yet stay
+				menus.refresh()
+			}
+		}
+	}
+
 	if os.Getenv("mobile") == "true" {
 		apptabs.SetTabLocation(container.TabLocationBottom)
 	} else {
@@ -130,16 +242,71 @@ func NewFileExplorer(w fyne.Window, root string, onOpenFile func(string), onExit
 	e.container = container.NewStack(apptabs)
 
 	// Refresh content (will check visibility)
-	e.refreshDir()
+	e.Reload()
 
 	return e
 }
 
+// checkLinks checks the links of every page of the site
+func (e *FileExplorer) checkLinks() {
+	pages, err := e.Site.Pages()
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	showBrokenLinks(e.window, "Links", e.Site.CheckLinks(pages), e.OnOpenFile)
+}
+
+// checkAliases shows the aliases of pages that collide with other pages
+func (e *FileExplorer) checkAliases() {
+	pages, err := e.Site.Pages()
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	showAliasCollisions(e.window, e.Site.AliasCollisions(pages), e.OnOpenFile)
+}
+
+// checkRules shows the pages whose front matter breaks the rules of the
+// site
+func (e *FileExplorer) checkRules() {
+	rules, err := e.Site.LoadRules()
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	if rules == nil {
+		dialog.ShowInformation("Front Matter Rules", "The site has no rules. Add them to .bayan/validation.toml, .yaml or .json.", e.window)
+		return
+	}
+	pages, err := e.Site.Pages()
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	showValidationErrors(e.window, rules.ValidatePages(pages), e.OnOpenFile)
+}
+
 // GetUI returns the container for this component
 func (e *FileExplorer) GetUI() fyne.CanvasObject {
 	return e.container
 }
 
+// Reload reads the content again, after pages were added or moved, and
+// lists the current folder
+func (e *FileExplorer) Reload() {
+	if e.Site.Multilingual() {
+		pages, err := e.Site.Pages()
+		if err != nil {
+			fyne.LogError("Failed to read content", err)
+		}
+		e.pages = pages
+	}
+	e.refreshDir()
+}
+
+// refreshDir lists the current folder, with the translations of the
+// content as last read
 func (e *FileExplorer) refreshDir() {
 	entries, err := os.ReadDir(e.CurrentPath)
 	if err != nil {
@@ -161,9 +328,37 @@ func (e *FileExplorer) refreshDir() {
 	}
 
 	sort.Slice(distinctDirs, func(i, j int) bool { return distinctDirs[i].Name() < distinctDirs[j].Name() })
-	sort.Slice(distinctFiles, func(i, j int) bool { return distinctFiles[i].Name() < distinctFiles[j].Name() })
+	sort.Slice(distinctFiles, func(i, j int) bool {
+		// The section page comes first
+		_, iSection := cms.IsBundleIndex(distinctFiles[i].Name())
+		_, jSection := cms.IsBundleIndex(distinctFiles[j].Name())
+		if iSection != jSection {
+			return iSection
+		}
+		return distinctFiles[i].Name() < distinctFiles[j].Name()
+	})
 
-	e.files = append(distinctDirs, distinctFiles...)
+	if e.Site.Multilingual() {
+		// Translations are shown next to their page rather than on their own
+		distinctFiles = e.groupTranslations(distinctFiles)
+	}
+
+	// Folders holding a page are shown as that page, sections are marked
+	e.files = nil
+	var bundles []explorerItem
+	for _, entry := range distinctDirs {
+		dir := filepath.Join(e.CurrentPath, entry.Name())
+		if index := cms.BundleIndex(dir, false); index != "" {
+			bundles = append(bundles, explorerItem{entry: entry, index: index})
+			continue
+		}
+		e.files = append(e.files, explorerItem{entry: entry, section: cms.BundleIndex(dir, true) != ""})
+	}
+	e.files = append(e.files, bundles...)
+	for _, entry := range distinctFiles {
+		_, section := cms.IsBundleIndex(entry.Name())
+		e.files = append(e.files, explorerItem{entry: entry, section: section})
+	}
 	e.pathLabel.SetText(filepath.Base(e.CurrentPath)) // Show only current folder name for brevity
 
 	// Check root for Up button visibility
@@ -181,10 +376,13 @@ func (e *FileExplorer) onItemTapped(id widget.ListItemID) {
 	if id >= len(e.files) {
 		return
 	}
-	entry := e.files[id]
-	fullPath := filepath.Join(e.CurrentPath, entry.Name())
+	item := e.files[id]
+	fullPath := filepath.Join(e.CurrentPath, item.entry.Name())
+	if item.index != "" {
+		fullPath = item.index
+	}
 
-	if entry.IsDir() {
+	if item.index == "" && item.entry.IsDir() {
 		e.CurrentPath = fullPath
 		e.refreshDir()
 	} else {
@@ -220,38 +418,264 @@ func (e *FileExplorer) showNewFolderDialog() {
 			dialog.ShowError(err, e.window)
 			return
 		}
-		e.refreshDir()
+		e.Reload()
 	}, e.window)
 
 	newFolderDialog.Resize(fyne.NewSize(400, 170))
 	newFolderDialog.Show()
 }
 
-func (e *FileExplorer) showNewFileDialog() {
-	newFileDialog := dialog.NewEntryDialog("New Markdown File", "File Name (without .md)", func(name string) {
-		if name == "" {
+// showNewFileDialog creates a page from an archetype, as a single file or
+// as a leaf bundle: a folder with an index.md, for pages with images.
+func (e *FileExplorer) showNewFileDialog(bundle bool) {
+	archetypes := cms.FindArchetypes(e.Site.Dir, e.Site.ThemePaths())
+	archetypes = append(archetypes, cms.Archetype{Name: "default"}) // Hugo's built-in
+
+	labels := make([]string, len(archetypes))
+	for i, a := range archetypes {
+		labels[i] = a.Label()
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("File Name (without .md)")
+
+	// The name follows the title until it is edited by hand
+	titleEntry := widget.NewEntry()
+	titleEntry.SetPlaceHolder("Title")
+	auto := ""
+	titleEntry.OnChanged = func(title string) {
+		if nameEntry.Text == auto {
+			auto = e.Site.Slug(title)
+			nameEntry.SetText(auto)
+		}
+	}
+
+	// Preselect what hugo new would use for this section
+	archetypeSelect := widget.NewSelect(labels, nil)
+	archetypeSelect.SetSelected(cms.LookupArchetype(archetypes, e.section()).Label())
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Title", titleEntry),
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Archetype", archetypeSelect),
+	}
+
+	title := "New Markdown File"
+	if bundle {
+		title = "New Page Bundle"
+		nameEntry.SetPlaceHolder("Folder Name")
+	}
+
+	newFileDialog := dialog.NewForm(title, "Create", "Cancel", items, func(ok bool) {
+		name := strings.TrimSpace(nameEntry.Text)
+		if !ok || name == "" {
 			return
 		}
-		if !strings.HasSuffix(name, ".md") {
-			name += ".md"
+		archetype := archetypes[archetypeSelect.SelectedIndex()]
+
+		// Directory archetypes create a bundle named after the page
+		pathOf := func(name string) string {
+			path := filepath.Join(e.CurrentPath, name)
+			switch {
+			case archetype.Dir:
+			case bundle:
+				path = filepath.Join(path, "index.md")
+			default:
+				path += ".md"
+			}
+			return path
 		}
-		path := filepath.Join(e.CurrentPath, name)
 
-		// Create empty file
-		f, err := os.Create(path)
+		// Names are made unique within the section, keeping the language
+		// of a translation such as post.ar. A name typed by hand is kept
+		// but for its spaces, as is _index, and post.ar unless it exists.
+		name = strings.Join(strings.Fields(strings.TrimSuffix(name, ".md")), "-")
+		lang, base := e.Site.SplitLang(name + ".md")
+		base = strings.TrimSuffix(base, ".md")
+		if _, err := os.Stat(pathOf(name)); base != "_index" && base != "index" && (lang == "" || err == nil) {
+			pages, err := e.Site.Pages()
+			if err != nil {
+				dialog.ShowError(err, e.window)
+				return
+			}
+			name = e.Site.UniqueSlug(pages, e.CurrentPath, e.section(), base, "")
+			if lang != "" {
+				name += "." + lang
+			}
+		}
+		path := pathOf(name)
+
+		file, err := cms.NewContent(archetype, e.RootPath, path, e.Site.ArchetypeSite(path), time.Now())
 		if err != nil {
 			dialog.ShowError(err, e.window)
 			return
 		}
-		f.Close()
+		if title := strings.TrimSpace(titleEntry.Text); title != "" && file != "" {
+			err := site.UpdatePage(file, func(meta map[string]interface{}) error {
+				meta["title"] = title
+				return nil
+			})
+			if err != nil {
+				dialog.ShowError(err, e.window)
+			}
+		}
 
-		e.refreshDir()
+		e.Reload()
 		// Optionally open it immediately
-		if e.OnOpenFile != nil {
-			e.OnOpenFile(path)
+		if e.OnOpenFile != nil && file != "" {
+			e.OnOpenFile(file)
 		}
 	}, e.window)
 
-	newFileDialog.Resize(fyne.NewSize(400, 170))
+	newFileDialog.Resize(fyne.NewSize(400, 270))
 	newFileDialog.Show()
 }
+
+// showMoveDialog renames or moves a page, a bundle or a folder within the
+// content, along with the translations named after a page. Pages keep
+// their old URLs as aliases.
+func (e *FileExplorer) showMoveDialog(item explorerItem) {
+	from := filepath.Join(e.CurrentPath, item.entry.Name())
+	name := item.entry.Name()
+	if !item.entry.IsDir() {
+		_, name = e.Site.SplitLang(name)
+	}
+	rel, _ := filepath.Rel(e.RootPath, filepath.Join(e.CurrentPath, name))
+
+	entry := widget.NewEntry()
+	entry.SetText(filepath.ToSlash(rel))
+	items := []*widget.FormItem{widget.NewFormItem("Path", entry)}
+
+	d := dialog.NewForm("Rename or Move", "Move", "Cancel", items, func(ok bool) {
+		target := strings.Trim(strings.TrimSpace(entry.Text), "/")
+		if !ok || target == "" || target == filepath.ToSlash(rel) {
+			return
+		}
+		to := filepath.Join(e.RootPath, filepath.FromSlash(target))
+		if r, err := filepath.Rel(e.RootPath, to); err != nil || r == "." || strings.HasPrefix(r, "..") {
+			dialog.ShowError(fmt.Errorf("%s is outside the content", target), e.window)
+			return
+		}
+
+		moves := map[string]string{from: to}
+		if !item.entry.IsDir() {
+			if filepath.Ext(to) == "" {
+				to += filepath.Ext(name)
+			}
+			moves = e.Site.TranslationMoves(filepath.Join(e.CurrentPath, name), to)
+		}
+
+		pages, err := e.Site.Pages()
+		if err != nil {
+			dialog.ShowError(err, e.window)
+			return
+		}
+		if err := e.Site.MovePages(pages, moves); err != nil {
+			dialog.ShowError(err, e.window)
+		}
+		e.Reload()
+	}, e.window)
+	d.Resize(fyne.NewSize(400, 170))
+	d.Show()
+}
+
+// section returns the content section of the current directory
+func (e *FileExplorer) section() string {
+	rel, err := filepath.Rel(e.RootPath, e.CurrentPath)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return strings.Split(filepath.ToSlash(rel), "/")[0]
+}
+
+// groupTranslations keeps one file per page out of its translations named
+// like post.ar.md: the one in the language that comes first.
+func (e *FileExplorer) groupTranslations(files []os.DirEntry) []os.DirEntry {
+	rank := func(name string) int {
+		lang, _ := e.Site.SplitLang(name)
+		if lang == "" {
+			return -1 // The default language
+		}
+		for i, l := range e.Site.Languages {
+			if l.Code == lang {
+				return i
+			}
+		}
+		return len(e.Site.Languages)
+	}
+
+	chosen := make(map[string]os.DirEntry)
+	var order []string
+	for _, file := range files {
+		_, base := e.Site.SplitLang(file.Name())
+		current, ok := chosen[base]
+		if !ok {
+			order = append(order, base)
+		}
+		if !ok || rank(file.Name()) < rank(current.Name()) {
+			chosen[base] = file
+		}
+	}
+
+	out := make([]os.DirEntry, len(order))
+	for i, base := range order {
+		out[i] = chosen[base]
+	}
+	return out
+}
+
+// translationButtons returns a button per language for the page at path,
+// opening the translation or offering to create it when it is missing
+func (e *FileExplorer) translationButtons(path string) []fyne.CanvasObject {
+	page := site.FindPage(e.pages, path)
+	if page == nil {
+		return nil
+	}
+	translations := site.Translations(e.pages, page)
+
+	var buttons []fyne.CanvasObject
+	for _, lang := range e.Site.Languages {
+		lang := lang
+		code := strings.ToUpper(lang.Code)
+		if t, ok := translations[lang.Code]; ok {
+			btn := widget.NewButton(code, func() {
+				if e.OnOpenFile != nil {
+					e.OnOpenFile(t.Path)
+				}
+			})
+			btn.Importance = widget.LowImportance
+			buttons = append(buttons, btn)
+			continue
+		}
+
+		btn := widget.NewButtonWithIcon(code, theme.ContentAddIcon(), func() {
+			e.confirmTranslate(page, lang)
+		})
+		btn.Importance = widget.WarningImportance
+		buttons = append(buttons, btn)
+	}
+	return buttons
+}
+
+// confirmTranslate offers to create the missing translation of a page
+func (e *FileExplorer) confirmTranslate(page *site.Page, lang site.Language) {
+	name := lang.Name
+	if name == "" {
+		name = lang.Code
+	}
+	message := fmt.Sprintf("%s has no %s version yet. Create it from a copy of its front matter?", page.Title(), name)
+	dialog.ShowConfirm("Translate", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		path, err := e.Site.Translate(page, lang.Code)
+		if err != nil {
+			dialog.ShowError(err, e.window)
+			return
+		}
+		e.Reload()
+		if e.OnOpenFile != nil {
+			e.OnOpenFile(path)
+		}
+	}, e.window)
+}
@@ -0,0 +1,481 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// valueField is the editor of a single front matter value
+type valueField struct {
+	widget fyne.CanvasObject
+
+	// value returns the edited value, converted back to the original type
+	value func() (interface{}, error)
+}
+
+// fieldTypes are the kinds of value that can be added to a group, with the
+// empty value each one starts with
+var fieldTypes = []string{"Text", "Number", "Decimal", "Yes/No", "Date", "List", "Group"}
+
+func emptyValue(kind string) interface{} {
+	switch kind {
+	case "Number":
+		return 0
+	case "Decimal":
+		return 0.0
+	case "Yes/No":
+		return false
+	case "Date":
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	case "List":
+		return []interface{}{}
+	case "Group":
+		return map[string]interface{}{}
+	default:
+		return ""
+	}
+}
+
+// newValueField builds the editor for val, recursing into maps and lists
+// of maps.
+func newValueField(w fyne.Window, val interface{}) *valueField {
+	switch v := val.(type) {
+	case bool:
+		check := widget.NewCheck("", nil)
+		check.Checked = v
+		return &valueField{
+			widget: check,
+			value:  func() (interface{}, error) { return check.Checked, nil },
+		}
+
+	case map[string]interface{}:
+		return newMapField(w, v)
+
+	case []map[string]interface{}: // TOML arrays of tables
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return newListField(w, items, true)
+
+	case []interface{}:
+		if isObjectList(v) {
+			return newListField(w, v, false)
+		}
+	}
+
+	entry := newValueEntry(val)
+	if t, ok := val.(time.Time); ok {
+		entry.ActionItem = widget.NewButtonWithIcon("", theme.CalendarIcon(), func() {
+			showDatePicker(w, entry, t)
+		})
+	}
+
+	return &valueField{
+		widget: entry,
+		value: func() (interface{}, error) {
+			if entry.Text == cms.FormatValue(val) {
+				// Untouched, keep the original value
+				return val, nil
+			}
+			v, err := cms.ParseValue(entry.Text, val)
+			if err != nil {
+				entry.Validate()
+				return nil, err
+			}
+			return v, nil
+		},
+	}
+}
+
+// newValueEntry creates an entry for a front matter value, which only
+// accepts text that can be converted back to the value's type.
+func newValueEntry(val interface{}) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(cms.FormatValue(val))
+
+	if _, err := cms.ParseValue(entry.Text, val); err != nil {
+		// Not editable as text, show it read-only
+		entry.Disable()
+		return entry
+	}
+	if _, ok := val.(string); !ok {
+		entry.Validator = func(text string) error {
+			_, err := cms.ParseValue(text, val)
+			return err
+		}
+	}
+	return entry
+}
+
+// showDatePicker lets the user pick the date of entry from a calendar,
+// keeping the time of day it already has.
+func showDatePicker(w fyne.Window, entry *widget.Entry, orig time.Time) {
+	current, err := cms.ParseDate(entry.Text, orig.Location())
+	if err != nil {
+		current = orig
+	}
+
+	var picker dialog.Dialog
+	calendar := widget.NewCalendar(current, func(day time.Time) {
+		picked := time.Date(day.Year(), day.Month(), day.Day(),
+			current.Hour(), current.Minute(), current.Second(), current.Nanosecond(), current.Location())
+		entry.SetText(cms.FormatDate(picked))
+		picker.Hide()
+	})
+	picker = dialog.NewCustom("Pick a date", "Cancel", calendar, w)
+	picker.Show()
+}
+
+// newMapField edits a nested map such as cover or params, with fields that
+// can be added and removed.
+func newMapField(w fyne.Window, m map[string]interface{}) *valueField {
+	keys := make([]string, 0, len(m))
+	fields := make(map[string]*valueField, len(m))
+	for k, v := range m {
+		keys = append(keys, k)
+		fields[k] = newValueField(w, v)
+	}
+	sort.Strings(keys)
+
+	box := container.NewVBox()
+	var rebuild func()
+	rebuild = func() {
+		form := widget.NewForm()
+		for _, k := range keys {
+			k := k
+			removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				delete(fields, k)
+				keys = removeString(keys, k)
+				rebuild()
+			})
+			removeBtn.Importance = widget.LowImportance
+			form.Append(k, container.NewBorder(nil, nil, nil, removeBtn, fields[k].widget))
+		}
+
+		addBtn := widget.NewButtonWithIcon("Add field", theme.ContentAddIcon(), func() {
+			showAddFieldDialog(w, func(k string, v interface{}) {
+				if _, exists := fields[k]; exists {
+					dialog.ShowError(fmt.Errorf("%s already exists", k), w)
+					return
+				}
+				keys = append(keys, k)
+				fields[k] = newValueField(w, v)
+				rebuild()
+			})
+		})
+		addBtn.Importance = widget.LowImportance
+		addBtn.Alignment = widget.ButtonAlignLeading
+
+		box.Objects = []fyne.CanvasObject{form, addBtn}
+		box.Refresh()
+	}
+	rebuild()
+
+	return &valueField{
+		widget: box,
+		value: func() (interface{}, error) {
+			out := make(map[string]interface{}, len(keys))
+			for _, k := range keys {
+				v, err := fields[k].value()
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", k, err)
+				}
+				out[k] = v
+			}
+			return out, nil
+		},
+	}
+}
+
+// newListField edits a list of maps such as resources, with items that can
+// be added, removed and reordered. typed is set for []map[string]interface{}
+// so the list is handed back with the same type.
+func newListField(w fyne.Window, list []interface{}, typed bool) *valueField {
+	items := make([]*valueField, len(list))
+	for i, item := range list {
+		items[i] = newValueField(w, item)
+	}
+
+	// New items start with the fields of the first one
+	template := map[string]interface{}{}
+	if len(list) > 0 {
+		if first, ok := list[0].(map[string]interface{}); ok {
+			template = emptyLike(first).(map[string]interface{})
+		}
+	}
+
+	box := container.NewVBox()
+	var rebuild func()
+	rebuild = func() {
+		box.Objects = nil
+		for i := range items {
+			i := i
+			upBtn := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+				items[i-1], items[i] = items[i], items[i-1]
+				rebuild()
+			})
+			downBtn := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+				items[i], items[i+1] = items[i+1], items[i]
+				rebuild()
+			})
+			removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				items = append(items[:i], items[i+1:]...)
+				rebuild()
+			})
+			if i == 0 {
+				upBtn.Disable()
+			}
+			if i == len(items)-1 {
+				downBtn.Disable()
+			}
+			for _, btn := range []*widget.Button{upBtn, downBtn, removeBtn} {
+				btn.Importance = widget.LowImportance
+			}
+
+			header := container.NewBorder(nil, nil,
+				widget.NewLabelWithStyle(fmt.Sprintf("Item %d", i+1), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				container.NewHBox(upBtn, downBtn, removeBtn),
+			)
+			box.Add(widget.NewCard("", "", container.NewBorder(header, nil, nil, nil, items[i].widget)))
+		}
+
+		addBtn := widget.NewButtonWithIcon("Add item", theme.ContentAddIcon(), func() {
+			items = append(items, newValueField(w, emptyLike(template)))
+			rebuild()
+		})
+		addBtn.Importance = widget.LowImportance
+		addBtn.Alignment = widget.ButtonAlignLeading
+		box.Add(addBtn)
+		box.Refresh()
+	}
+	rebuild()
+
+	return &valueField{
+		widget: box,
+		value: func() (interface{}, error) {
+			out := make([]interface{}, len(items))
+			typedOut := make([]map[string]interface{}, len(items))
+			for i, item := range items {
+				v, err := item.value()
+				if err != nil {
+					return nil, fmt.Errorf("item %d: %w", i+1, err)
+				}
+				out[i] = v
+				typedOut[i], _ = v.(map[string]interface{})
+			}
+			if typed {
+				return typedOut, nil
+			}
+			return out, nil
+		},
+	}
+}
+
+// showAddFieldDialog asks for the name and type of a new field
+func showAddFieldDialog(w fyne.Window, onAdd func(key string, val interface{})) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("e.g. image")
+	typeSelect := widget.NewSelect(fieldTypes, nil)
+	typeSelect.SetSelected(fieldTypes[0])
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Type", typeSelect),
+	}
+
+	addDialog := dialog.NewForm("Add Field", "Add", "Cancel", items, func(ok bool) {
+		name := strings.TrimSpace(nameEntry.Text)
+		if !ok || name == "" {
+			return
+		}
+		onAdd(name, emptyValue(typeSelect.Selected))
+	}, w)
+
+	addDialog.Resize(fyne.NewSize(400, 200))
+	addDialog.Show()
+}
+
+// isObjectList reports whether list is a non-empty list of maps
+func isObjectList(list []interface{}) bool {
+	if len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// emptyLike returns an empty value of the same shape as val
+func emptyLike(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = emptyLike(item)
+		}
+		return out
+	case bool:
+		return false
+	case int:
+		return 0
+	case int64:
+		return int64(0)
+	case uint64:
+		return uint64(0)
+	case float64:
+		return 0.0
+	case time.Time:
+		return emptyValue("Date")
+	case []interface{}, []string:
+		return []interface{}{}
+	case []map[string]interface{}:
+		return []map[string]interface{}{}
+	default:
+		return ""
+	}
+}
+
+func removeString(list []string, s string) []string {
+	out := list[:0]
+	for _, item := range list {
+		if item != s {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// newTermsField edits the terms of a taxonomy such as tags, suggesting the
+// terms already used on the site while typing. Terms spelled like an
+// existing one, e.g. "golang" for "Golang", take the existing spelling.
+func newTermsField(val interface{}, known []string) *valueField {
+	var terms []string
+	switch v := val.(type) {
+	case string:
+		if v != "" {
+			terms = []string{v}
+		}
+	default:
+		for _, item := range v.([]interface{}) {
+			terms = append(terms, fmt.Sprint(item))
+		}
+	}
+	orig := append([]string{}, terms...)
+
+	chips := container.NewHBox()
+	suggestions := container.NewHBox()
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("Add a term")
+
+	has := func(term string) bool {
+		for _, t := range terms {
+			if site.TermKey(t) == site.TermKey(term) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var rebuild func()
+	add := func(term string) {
+		term = strings.TrimSpace(term)
+		if term == "" || has(term) {
+			entry.SetText("")
+			return
+		}
+		for _, k := range known {
+			if site.TermKey(k) == site.TermKey(term) {
+				term = k
+				break
+			}
+		}
+		terms = append(terms, term)
+		entry.SetText("")
+		rebuild()
+	}
+
+	rebuild = func() {
+		chips.Objects = nil
+		for _, term := range terms {
+			term := term
+			chip := widget.NewButtonWithIcon(term, theme.CancelIcon(), func() {
+				terms = removeString(terms, term)
+				rebuild()
+			})
+			chip.IconPlacement = widget.ButtonIconTrailingText
+			chips.Add(chip)
+		}
+		chips.Refresh()
+	}
+	rebuild()
+
+	entry.OnSubmitted = add
+	entry.OnChanged = func(text string) {
+		suggestions.Objects = nil
+		text = strings.ToLower(strings.TrimSpace(text))
+		if text != "" {
+			for _, k := range known {
+				if len(suggestions.Objects) == 6 {
+					break
+				}
+				if strings.Contains(strings.ToLower(k), text) && !has(k) {
+					k := k
+					btn := widget.NewButton(k, func() { add(k) })
+					btn.Importance = widget.LowImportance
+					suggestions.Add(btn)
+				}
+			}
+		}
+		suggestions.Refresh()
+	}
+
+	return &valueField{
+		widget: container.NewVBox(
+			container.NewHScroll(chips),
+			entry,
+			container.NewHScroll(suggestions),
+		),
+		value: func() (interface{}, error) {
+			if pending := strings.TrimSpace(entry.Text); pending != "" {
+				add(pending)
+			}
+			if strings.Join(terms, "\x00") == strings.Join(orig, "\x00") {
+				return val, nil
+			}
+			return site.TermsLike(terms, val), nil
+		},
+	}
+}
+
+// isTermsValue reports whether val can be edited as taxonomy terms: a
+// string or a list of strings
+func isTermsValue(val interface{}) bool {
+	switch v := val.(type) {
+	case string:
+		return true
+	case []interface{}:
+		for _, item := range v {
+			if _, ok := item.(string); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
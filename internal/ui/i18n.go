@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// I18nEditor shows the translation strings of the site as a grid of IDs
+// and languages, with the missing ones highlighted, and edits the site's
+// i18n files. Strings of themes show as placeholders until overridden.
+type I18nEditor struct {
+	Site *site.SiteConfig
+
+	window      fyne.Window
+	container   *fyne.Container
+	grid        *fyne.Container
+	filter      *widget.Entry
+	missingOnly *widget.Check
+
+	strings *site.I18n
+}
+
+// NewI18nEditor creates the translation strings screen of a site
+func NewI18nEditor(w fyne.Window, cfg *site.SiteConfig) *I18nEditor {
+	e := &I18nEditor{
+		Site:   cfg,
+		window: w,
+		grid:   container.NewVBox(),
+	}
+
+	e.filter = widget.NewEntry()
+	e.filter.SetPlaceHolder("Filter strings")
+	e.filter.OnChanged = func(string) { e.rebuild() }
+	e.missingOnly = widget.NewCheck("Missing only", func(bool) { e.rebuild() })
+
+	addBtn := widget.NewButtonWithIcon("", theme.ContentAddIcon(), e.showAddDialog)
+	refreshBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), e.refresh)
+	saveBtn := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		if err := e.strings.Save(); err != nil {
+			dialog.ShowError(err, e.window)
+		}
+	})
+	saveBtn.Importance = widget.HighImportance
+
+	topBar := container.NewBorder(nil, nil, e.missingOnly,
+		container.NewHBox(refreshBtn, addBtn, saveBtn), e.filter)
+
+	e.container = container.NewPadded(
+		container.NewBorder(topBar, nil, nil, nil, container.NewScroll(e.grid)),
+	)
+	e.refresh()
+	return e
+}
+
+// GetUI returns the container for this component
+func (e *I18nEditor) GetUI() fyne.CanvasObject {
+	return e.container
+}
+
+// refresh reads the i18n files and templates again
+func (e *I18nEditor) refresh() {
+	t, err := e.Site.LoadI18n()
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	e.strings = t
+	e.rebuild()
+}
+
+// rebuild lays out the grid of the IDs passing the filters
+func (e *I18nEditor) rebuild() {
+	if e.strings == nil {
+		return
+	}
+	t := e.strings
+
+	header := []fyne.CanvasObject{widget.NewLabelWithStyle("ID", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})}
+	for _, lang := range t.Langs {
+		header = append(header, widget.NewLabelWithStyle(langName(e.Site, lang), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	}
+	rows := []fyne.CanvasObject{container.NewGridWithColumns(len(t.Langs)+1, header...)}
+
+	filter := strings.ToLower(e.filter.Text)
+	for _, id := range t.IDs {
+		if filter != "" && !strings.Contains(strings.ToLower(id), filter) {
+			continue
+		}
+		missing := false
+		for _, lang := range t.Langs {
+			missing = missing || len(t.Missing(lang, id)) > 0
+		}
+		if e.missingOnly.Checked && !missing {
+			continue
+		}
+
+		cells := []fyne.CanvasObject{e.idCell(id)}
+		for _, lang := range t.Langs {
+			cells = append(cells, e.stringCell(lang, id))
+		}
+		rows = append(rows, container.NewGridWithColumns(len(t.Langs)+1, cells...))
+	}
+	if len(rows) == 1 {
+		rows = append(rows, widget.NewLabel("No strings to show"))
+	}
+
+	e.grid.Objects = rows
+	e.grid.Refresh()
+}
+
+// idCell shows an ID, whether templates use it, and makes it plural
+func (e *I18nEditor) idCell(id string) fyne.CanvasObject {
+	label := widget.NewLabel(id)
+	label.Truncation = fyne.TextTruncateEllipsis
+	if !e.strings.Used(id) {
+		label.Importance = widget.LowImportance
+		label.SetText(id + " (unused)")
+	}
+	if e.strings.Plural(id) {
+		return label
+	}
+
+	pluralBtn := widget.NewButton("1/n", func() {
+		e.strings.MakePlural(id)
+		e.rebuild()
+	})
+	pluralBtn.Importance = widget.LowImportance
+	return container.NewBorder(nil, nil, nil, pluralBtn, label)
+}
+
+// stringCell edits the translation of id in a language: in place for a
+// plain string, in a dialog for plural forms
+func (e *I18nEditor) stringCell(lang, id string) fyne.CanvasObject {
+	t := e.strings
+	s, inherited := t.Get(lang, id)
+	highlight := newHighlight()
+	highlight.Hidden = len(t.Missing(lang, id)) == 0
+
+	if t.Plural(id) {
+		text := s["other"]
+		if text == "" {
+			text = "—"
+		}
+		btn := widget.NewButton(text, func() { e.showPluralDialog(lang, id) })
+		btn.Alignment = widget.ButtonAlignLeading
+		if inherited {
+			btn.Importance = widget.LowImportance
+		}
+		return container.NewStack(highlight, btn)
+	}
+
+	entry := widget.NewEntry()
+	if inherited {
+		entry.SetPlaceHolder(s["other"])
+	} else {
+		entry.SetText(s["other"])
+	}
+	entry.OnChanged = func(text string) {
+		t.Set(lang, id, "other", text)
+		highlight.Hidden = len(t.Missing(lang, id)) == 0
+		highlight.Refresh()
+	}
+	return container.NewStack(highlight, entry)
+}
+
+// showPluralDialog edits the plural forms of id the language uses
+func (e *I18nEditor) showPluralDialog(lang, id string) {
+	t := e.strings
+	s, inherited := t.Get(lang, id)
+
+	entries := make(map[string]*widget.Entry)
+	var items []*widget.FormItem
+	for _, form := range site.PluralForms(lang) {
+		entry := widget.NewEntry()
+		if inherited {
+			entry.SetPlaceHolder(s[form])
+		} else {
+			entry.SetText(s[form])
+		}
+		entries[form] = entry
+		items = append(items, widget.NewFormItem(form, entry))
+	}
+
+	dialog.ShowForm(id+" · "+langName(e.Site, lang), "Done", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		for form, entry := range entries {
+			if inherited && entry.Text == "" {
+				continue
+			}
+			if !inherited && entry.Text == s[form] {
+				continue
+			}
+			t.Set(lang, id, form, entry.Text)
+		}
+		e.rebuild()
+	}, e.window)
+}
+
+// showAddDialog adds a string ID to translate
+func (e *I18nEditor) showAddDialog() {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("e.g. readMore")
+	dialog.ShowForm("Add String", "Add", "Cancel", []*widget.FormItem{widget.NewFormItem("ID", entry)}, func(ok bool) {
+		id := strings.TrimSpace(entry.Text)
+		if !ok || id == "" {
+			return
+		}
+		e.strings.Add(id)
+		e.filter.SetText(id)
+	}, e.window)
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// showBrokenLinks lists broken links by file and line. Tapping one opens
+// its page.
+func showBrokenLinks(w fyne.Window, title string, broken []site.BrokenLink, open func(path string)) {
+	if len(broken) == 0 {
+		dialog.ShowInformation(title, "Every link leads somewhere.", w)
+		return
+	}
+
+	var d dialog.Dialog
+	list := widget.NewList(
+		func() int { return len(broken) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			b := broken[id]
+			box := o.(*fyne.Container)
+			box.Objects[0].(*widget.Label).SetText(fmt.Sprintf("%s:%d", b.Page.Rel, b.Line))
+			box.Objects[1].(*widget.Label).SetText(fmt.Sprintf("%s %s: %s", b.Kind, b.Target, b.Reason))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		d.Hide()
+		open(broken[id].Page.Path)
+	}
+
+	message := widget.NewLabel(fmt.Sprintf("%d broken links. Hugo fails the build on broken refs.", len(broken)))
+	d = dialog.NewCustom(title, "Close", container.NewBorder(message, nil, nil, nil, list), w)
+	d.Resize(fyne.NewSize(600, 450))
+	d.Show()
+}
@@ -0,0 +1,372 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// MenuEditor shows a menu of the site as a tree, with the entries from the
+// config and from page front matter side by side
+type MenuEditor struct {
+	Site *site.SiteConfig
+
+	window     fyne.Window
+	container  *fyne.Container
+	tree       *fyne.Container
+	menuSelect *widget.Select
+	langSelect *widget.Select
+
+	pages []*site.Page
+	menu  *site.Menu
+}
+
+// NewMenuEditor creates the menu screen of a site. The menus are read when
+// it is refreshed.
+func NewMenuEditor(w fyne.Window, cfg *site.SiteConfig) *MenuEditor {
+	m := &MenuEditor{
+		Site:   cfg,
+		window: w,
+		tree:   container.NewVBox(),
+	}
+
+	m.menuSelect = widget.NewSelect(nil, func(string) { m.loadMenu() })
+	m.menuSelect.PlaceHolder = "Menu"
+
+	codes := make([]string, len(cfg.Languages))
+	for i, lang := range cfg.Languages {
+		codes[i] = lang.Code
+	}
+	m.langSelect = widget.NewSelect(codes, func(string) { m.loadMenu() })
+	if !cfg.Multilingual() {
+		m.langSelect.Hide()
+	}
+
+	newMenuBtn := widget.NewButtonWithIcon("", theme.ContentAddIcon(), m.showNewMenuDialog)
+	addLinkBtn := widget.NewButtonWithIcon("Link", theme.ContentAddIcon(), m.showAddLinkDialog)
+	addPageBtn := widget.NewButtonWithIcon("Page", theme.DocumentCreateIcon(), m.showAddPageDialog)
+	saveBtn := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), m.save)
+	saveBtn.Importance = widget.HighImportance
+
+	topBar := container.NewBorder(
+		nil,
+		nil,
+		container.NewHBox(m.menuSelect, m.langSelect, newMenuBtn),
+		container.NewHBox(addLinkBtn, addPageBtn, saveBtn),
+	)
+
+	m.container = container.NewPadded(
+		container.NewBorder(topBar, nil, nil, nil, container.NewVScroll(m.tree)),
+	)
+
+	return m
+}
+
+// GetUI returns the container for this component
+func (m *MenuEditor) GetUI() fyne.CanvasObject {
+	return m.container
+}
+
+// refresh reads the pages and the menus again
+func (m *MenuEditor) refresh() {
+	pages, err := m.Site.Pages()
+	if err != nil {
+		fyne.LogError("Failed to read content", err)
+	}
+	m.pages = pages
+
+	names := m.Site.MenuNames(m.pages)
+	m.menuSelect.Options = names
+
+	// Keep showing the same menu, selecting main when there's none yet
+	selected := m.menuSelect.Selected
+	if selected == "" && len(names) > 0 {
+		selected = names[0]
+		for _, name := range names {
+			if name == "main" {
+				selected = name
+			}
+		}
+	}
+	m.menuSelect.Selected = selected
+	m.menuSelect.Refresh()
+	if m.langSelect.Selected == "" && len(m.Site.Languages) > 0 {
+		m.langSelect.Selected = m.Site.Languages[0].Code
+		m.langSelect.Refresh()
+	}
+
+	m.loadMenu()
+}
+
+func (m *MenuEditor) loadMenu() {
+	if m.menuSelect.Selected == "" {
+		m.menu = nil
+		m.tree.Objects = []fyne.CanvasObject{
+			container.NewCenter(widget.NewLabel("No menus yet, add one with +")),
+		}
+		m.tree.Refresh()
+		return
+	}
+	m.menu = m.Site.LoadMenu(m.menuSelect.Selected, m.langSelect.Selected, m.pages)
+	m.rebuild()
+}
+
+// rebuild redraws the tree of the current menu
+func (m *MenuEditor) rebuild() {
+	m.tree.Objects = nil
+	var add func(items []*site.MenuItem, depth int)
+	add = func(items []*site.MenuItem, depth int) {
+		for _, item := range items {
+			m.tree.Add(m.newRow(item, depth))
+			add(item.Children, depth+1)
+		}
+	}
+	add(m.menu.Items, 0)
+
+	if len(m.menu.Items) == 0 {
+		m.tree.Add(container.NewCenter(widget.NewLabel("This menu is empty")))
+	}
+	m.tree.Refresh()
+}
+
+// newRow shows an entry with the buttons to move, nest, edit and remove it
+func (m *MenuEditor) newRow(item *site.MenuItem, depth int) fyne.CanvasObject {
+	siblings := m.menu.Siblings(item)
+	index := 0
+	for i, it := range siblings {
+		if it == item {
+			index = i
+		}
+	}
+
+	name := widget.NewLabelWithStyle(item.Label(), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	name.Truncation = fyne.TextTruncateEllipsis
+	source := "config"
+	if item.Page != nil {
+		source = item.Page.Rel
+	}
+	link := widget.NewLabel(item.Link() + "  ·  " + source)
+	link.Truncation = fyne.TextTruncateEllipsis
+	link.Importance = widget.LowImportance
+
+	upBtn := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+		m.menu.Move(item, -1)
+		m.rebuild()
+	})
+	downBtn := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+		m.menu.Move(item, 1)
+		m.rebuild()
+	})
+	outdentBtn := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		m.menu.Outdent(item)
+		m.rebuild()
+	})
+	indentBtn := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
+		m.menu.Indent(item)
+		m.rebuild()
+	})
+	editBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+		m.showEditDialog(item)
+	})
+	removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		m.menu.Remove(item)
+		m.rebuild()
+	})
+	if index == 0 {
+		upBtn.Disable()
+		indentBtn.Disable()
+	}
+	if index == len(siblings)-1 {
+		downBtn.Disable()
+	}
+	if item.ParentItem() == nil {
+		outdentBtn.Disable()
+	}
+	for _, btn := range []*widget.Button{upBtn, downBtn, outdentBtn, indentBtn, editBtn, removeBtn} {
+		btn.Importance = widget.LowImportance
+	}
+
+	// Dragging the handle moves the entry by as many rows as it was dragged
+	var row fyne.CanvasObject
+	handle := newDragHandle(func(dy float32) {
+		steps := int(math.Round(float64(dy / row.MinSize().Height)))
+		if steps != 0 {
+			m.menu.Move(item, steps)
+			m.rebuild()
+		}
+	})
+
+	indent := canvas.NewRectangle(nil)
+	indent.SetMinSize(fyne.NewSize(float32(depth)*theme.IconInlineSize()*1.5, 0))
+
+	row = container.NewBorder(nil, nil,
+		container.NewHBox(indent, handle),
+		container.NewHBox(upBtn, downBtn, outdentBtn, indentBtn, editBtn, removeBtn),
+		container.NewVBox(name, link),
+	)
+	return row
+}
+
+func (m *MenuEditor) showEditDialog(item *site.MenuItem) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(item.Name)
+	items := []*widget.FormItem{widget.NewFormItem("Name", nameEntry)}
+
+	urlEntry := widget.NewEntry()
+	if item.Page != nil {
+		// Hugo uses the page title when the entry has no name
+		nameEntry.SetPlaceHolder(item.Page.LinkTitle())
+	} else if item.PageRef == "" {
+		urlEntry.SetText(item.URL)
+		items = append(items, widget.NewFormItem("URL", urlEntry))
+	}
+
+	editDialog := dialog.NewForm("Edit Menu Entry", "Apply", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		name := strings.TrimSpace(nameEntry.Text)
+		if name == "" && item.Page == nil {
+			dialog.ShowError(fmt.Errorf("the entry needs a name"), m.window)
+			return
+		}
+		m.menu.Rename(item, name)
+		if item.Page == nil && item.PageRef == "" {
+			item.URL = strings.TrimSpace(urlEntry.Text)
+		}
+		m.rebuild()
+	}, m.window)
+
+	editDialog.Resize(fyne.NewSize(400, 200))
+	editDialog.Show()
+}
+
+func (m *MenuEditor) showNewMenuDialog() {
+	newMenuDialog := dialog.NewEntryDialog("New Menu", "Menu Name", func(name string) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return
+		}
+		m.menuSelect.Options = append(m.menuSelect.Options, name)
+		m.menuSelect.SetSelected(name)
+	}, m.window)
+
+	newMenuDialog.Resize(fyne.NewSize(400, 170))
+	newMenuDialog.Show()
+}
+
+func (m *MenuEditor) showAddLinkDialog() {
+	if m.menu == nil {
+		return
+	}
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("e.g. GitHub")
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("URL", urlEntry),
+	}
+
+	addDialog := dialog.NewForm("Add Link", "Add", "Cancel", items, func(ok bool) {
+		name := strings.TrimSpace(nameEntry.Text)
+		url := strings.TrimSpace(urlEntry.Text)
+		if !ok || name == "" || url == "" {
+			return
+		}
+		m.menu.AddLink(name, url)
+		m.rebuild()
+	}, m.window)
+
+	addDialog.Resize(fyne.NewSize(400, 200))
+	addDialog.Show()
+}
+
+func (m *MenuEditor) showAddPageDialog() {
+	if m.menu == nil {
+		return
+	}
+
+	var candidates []*site.Page
+	var labels []string
+	for _, p := range m.pages {
+		if m.Site.Multilingual() && p.Lang != m.menu.Lang {
+			continue
+		}
+		candidates = append(candidates, p)
+		labels = append(labels, p.Rel)
+	}
+
+	pageSelect := widget.NewSelect(labels, nil)
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Page title")
+	pageSelect.OnChanged = func(string) {
+		nameEntry.SetPlaceHolder(candidates[pageSelect.SelectedIndex()].LinkTitle())
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Page", pageSelect),
+		widget.NewFormItem("Name", nameEntry),
+	}
+
+	addDialog := dialog.NewForm("Add Page", "Add", "Cancel", items, func(ok bool) {
+		if !ok || pageSelect.SelectedIndex() < 0 {
+			return
+		}
+		item := m.menu.AddPage(candidates[pageSelect.SelectedIndex()])
+		item.Name = strings.TrimSpace(nameEntry.Text)
+		m.rebuild()
+	}, m.window)
+
+	addDialog.Resize(fyne.NewSize(400, 200))
+	addDialog.Show()
+}
+
+func (m *MenuEditor) save() {
+	if m.menu == nil {
+		return
+	}
+	if err := m.menu.Save(); err != nil {
+		dialog.ShowError(err, m.window)
+		return
+	}
+	m.refresh()
+	dialog.ShowInformation("Menus", "Menu saved", m.window)
+}
+
+// dragHandle is an icon that reports how far it was dragged vertically
+type dragHandle struct {
+	widget.Icon
+
+	dy     float32
+	onDrop func(dy float32)
+}
+
+func newDragHandle(onDrop func(dy float32)) *dragHandle {
+	h := &dragHandle{onDrop: onDrop}
+	h.SetResource(theme.MenuIcon())
+	h.ExtendBaseWidget(h)
+	return h
+}
+
+// Dragged is called while the handle is dragged
+func (h *dragHandle) Dragged(e *fyne.DragEvent) {
+	h.dy += e.Dragged.DY
+}
+
+// DragEnd is called when the handle is dropped
+func (h *dragHandle) DragEnd() {
+	dy := h.dy
+	h.dy = 0
+	h.onDrop(dy)
+}
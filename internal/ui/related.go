@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// relatedView shows the pages Hugo lists as related to the page as edited,
+// and keywords that would relate more pages, added to the front matter
+// with a tap
+func (e *Editor) relatedView(refresh func()) fyne.CanvasObject {
+	page := site.FindPage(e.pages, e.FullPath)
+	if page == nil {
+		return container.NewCenter(widget.NewLabel("Save the page to see its related content"))
+	}
+
+	// The fields as edited rather than as saved
+	edited := *page
+	edited.Body = e.bodyEntry.Text
+	edited.Meta = make(map[string]interface{}, len(e.widgetMap))
+	for k, field := range e.widgetMap {
+		if val, err := field.value(); err == nil {
+			edited.Meta[k] = val
+		}
+	}
+
+	cfg := e.Site.Related()
+	var indices []string
+	for _, index := range cfg.Indices {
+		indices = append(indices, fmt.Sprintf("%s (%d)", index.Name, index.Weight))
+	}
+	about := widget.NewLabel(fmt.Sprintf("Related by %s, with a threshold of %d.", strings.Join(indices, ", "), cfg.Threshold))
+	about.Wrapping = fyne.TextWrapWord
+	about.Importance = widget.LowImportance
+
+	rows := container.NewVBox(about, widget.NewLabelWithStyle("Related pages", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	related := e.Site.RelatedPages(e.pages, &edited)
+	if len(related) == 0 {
+		rows.Add(widget.NewLabel("No page is related yet"))
+	}
+	for _, r := range related {
+		r := r
+		openBtn := widget.NewButton(r.Page.Title(), func() { e.open(r.Page.Path) })
+		openBtn.Alignment = widget.ButtonAlignLeading
+		openBtn.Importance = widget.LowImportance
+		shared := widget.NewLabel(fmt.Sprintf("%d · %s", r.Weight, strings.Join(r.Keywords, ", ")))
+		shared.Truncation = fyne.TextTruncateEllipsis
+		rows.Add(container.NewGridWithColumns(2, openBtn, shared))
+	}
+
+	suggestions := e.Site.SuggestKeywords(e.pages, &edited, 10)
+	if len(suggestions) > 0 {
+		rows.Add(widget.NewLabelWithStyle("Keywords relating more pages", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	}
+	for _, s := range suggestions {
+		s := s
+		addBtn := widget.NewButtonWithIcon(s.Index+": "+s.Keyword, theme.ContentAddIcon(), func() {
+			e.addKeyword(s.Index, s.Keyword)
+			refresh()
+		})
+		addBtn.Alignment = widget.ButtonAlignLeading
+		gain := fmt.Sprintf("relates %d more pages", s.Gain)
+		if s.InText {
+			gain += " · in the text"
+		}
+		rows.Add(container.NewGridWithColumns(2, addBtn, widget.NewLabel(gain)))
+	}
+	return rows
+}
+
+// addKeyword adds a keyword to a list of the front matter, such as tags,
+// creating it when the page has none
+func (e *Editor) addKeyword(name, keyword string) {
+	k := e.fieldKey(name)
+	if k == "" {
+		e.addField(name, []interface{}{keyword})
+		e.refreshForm()
+		return
+	}
+
+	val, err := e.widgetMap[k].value()
+	if err != nil {
+		return
+	}
+	var terms []string
+	switch v := val.(type) {
+	case string:
+		if v != "" {
+			terms = []string{v}
+		}
+	case []string:
+		terms = v
+	case []interface{}:
+		for _, item := range v {
+			terms = append(terms, fmt.Sprint(item))
+		}
+	}
+	e.addField(k, site.TermsLike(append(terms, keyword), val))
+	e.refreshForm()
+}
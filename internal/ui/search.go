@@ -0,0 +1,277 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// allOption is the choice of a filter that lets every page through
+const allOption = "All"
+
+// ContentSearch searches the titles, front matter and text of the pages
+// of the site, filtered by section, language, status and term. The index
+// is kept in the app storage and brought up to date in the background.
+type ContentSearch struct {
+	Site       *site.SiteConfig
+	OnOpenFile func(string)
+
+	window    fyne.Window
+	container *fyne.Container
+	query     *widget.Entry
+	section   *widget.Select
+	lang      *widget.Select
+	status    *widget.Select
+	taxonomy  *widget.Select
+	term      *widget.Select
+	info      *widget.Label
+	list      *widget.List
+
+	index    *site.SearchIndex // nil until read
+	indexing bool
+	results  []site.SearchResult
+}
+
+// NewContentSearch creates the search screen of a site. The index is read
+// when it is refreshed.
+func NewContentSearch(w fyne.Window, cfg *site.SiteConfig, onOpenFile func(string)) *ContentSearch {
+	s := &ContentSearch{
+		Site:       cfg,
+		OnOpenFile: onOpenFile,
+		window:     w,
+		info:       widget.NewLabel(""),
+	}
+	s.info.Importance = widget.LowImportance
+
+	s.query = widget.NewEntry()
+	s.query.SetPlaceHolder("Search titles, front matter and text")
+	s.query.OnChanged = func(string) { s.search() }
+
+	s.section = widget.NewSelect(nil, func(string) { s.search() })
+	s.section.PlaceHolder = "Section"
+	s.status = widget.NewSelect([]string{allOption, site.StatusDraft, site.StatusScheduled, site.StatusPublished, site.StatusExpired}, func(string) { s.search() })
+	s.status.PlaceHolder = "Status"
+	s.term = widget.NewSelect(nil, func(string) { s.search() })
+	s.term.PlaceHolder = "Term"
+
+	var taxonomies []string
+	for _, plural := range cfg.Taxonomies {
+		taxonomies = append(taxonomies, plural)
+	}
+	sort.Strings(taxonomies)
+	s.taxonomy = widget.NewSelect(append([]string{allOption}, taxonomies...), func(string) {
+		s.term.ClearSelected()
+		s.refreshTerms()
+		s.search()
+	})
+	s.taxonomy.PlaceHolder = "Taxonomy"
+
+	filters := container.NewHBox(s.section, s.status, s.taxonomy, s.term)
+	if cfg.Multilingual() {
+		codes := []string{allOption}
+		for _, lang := range cfg.Languages {
+			codes = append(codes, lang.Code)
+		}
+		s.lang = widget.NewSelect(codes, func(string) { s.search() })
+		s.lang.PlaceHolder = "Language"
+		filters.Add(s.lang)
+	}
+
+	s.list = widget.NewList(
+		func() int { return len(s.results) },
+		func() fyne.CanvasObject {
+			title := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			title.Truncation = fyne.TextTruncateEllipsis
+			rel := widget.NewLabel("")
+			rel.Importance = widget.LowImportance
+			snippet := widget.NewRichText()
+			snippet.Truncation = fyne.TextTruncateEllipsis
+			return container.NewVBox(container.NewBorder(nil, nil, nil, rel, title), snippet)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			r := s.results[id]
+			box := o.(*fyne.Container)
+			head := box.Objects[0].(*fyne.Container)
+			head.Objects[0].(*widget.Label).SetText(r.Doc.Title)
+			head.Objects[1].(*widget.Label).SetText(r.Doc.Rel)
+			snippet := box.Objects[1].(*widget.RichText)
+			snippet.Segments = snippetSegments(r)
+			snippet.Refresh()
+		},
+	)
+	s.list.OnSelected = func(id widget.ListItemID) {
+		s.list.UnselectAll()
+		if s.OnOpenFile != nil {
+			s.OnOpenFile(s.results[id].Doc.Path)
+		}
+	}
+
+	refreshBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), s.refresh)
+	topBar := container.NewVBox(
+		container.NewBorder(nil, nil, nil, refreshBtn, s.query),
+		container.NewHScroll(filters),
+		s.info,
+	)
+	s.container = container.NewPadded(container.NewBorder(topBar, nil, nil, nil, s.list))
+	return s
+}
+
+// GetUI returns the container for this component
+func (s *ContentSearch) GetUI() fyne.CanvasObject {
+	return s.container
+}
+
+// refresh brings the saved index up to date with the content in the
+// background, then searches again
+func (s *ContentSearch) refresh() {
+	if s.indexing {
+		return
+	}
+	s.indexing = true
+	if s.index == nil {
+		s.info.SetText("Indexing the content…")
+	}
+
+	store := fyne.CurrentApp().Storage()
+	name, changesName := s.Site.SearchIndexName()
+	cfg := s.Site.Snapshot() // Saving the settings replaces s.Site
+	go func() {
+		ix := cfg.NewSearchIndex()
+		saved := false
+		if r, err := store.Open(name); err == nil {
+			var changes fyne.URIReadCloser
+			if changes, err = store.Open(changesName); err == nil {
+				ix, err = cfg.ReadSearchIndex(r, changes)
+				changes.Close()
+			} else {
+				ix, err = cfg.ReadSearchIndex(r, nil)
+			}
+			r.Close()
+			saved = err == nil && len(ix.Docs) > 0
+		}
+		changed, err := ix.Update(cfg)
+		if err == nil && len(changed) > 0 {
+			if werr := saveSearchIndex(store, name, changesName, ix, changed, saved); werr != nil {
+				fyne.LogError("Failed to save the search index", werr)
+			}
+		}
+
+		fyne.Do(func() {
+			s.indexing = false
+			if err != nil {
+				s.info.SetText(err.Error())
+				return
+			}
+			s.index = ix
+			s.section.Options = append([]string{allOption}, ix.Sections()...)
+			s.refreshTerms()
+			s.search()
+		})
+	}()
+}
+
+// saveSearchIndex adds the pages that changed to the changes saved after
+// the index, or writes the whole index again when it wasn't saved or the
+// changes grew too many
+func saveSearchIndex(store fyne.Storage, name, changesName string, ix *site.SearchIndex, changed []string, saved bool) error {
+	if saved && !ix.Compact() {
+		if r, err := store.Open(changesName); err == nil {
+			uri := r.URI()
+			r.Close()
+			if w, err := storage.Appender(uri); err == nil {
+				defer w.Close()
+				return ix.WriteChanges(w, changed)
+			}
+		} else if w, err := store.Create(changesName); err == nil {
+			defer w.Close()
+			return ix.WriteChanges(w, changed)
+		}
+	}
+
+	w, err := store.Save(name)
+	if err != nil {
+		if w, err = store.Create(name); err != nil {
+			return err
+		}
+	}
+	err = ix.Write(w)
+	w.Close()
+	if err != nil {
+		return err
+	}
+	if err = store.Remove(changesName); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// refreshTerms offers the terms of the chosen taxonomy
+func (s *ContentSearch) refreshTerms() {
+	if s.index == nil || selected(s.taxonomy) == "" {
+		s.term.Options = nil
+	} else {
+		s.term.Options = s.index.Terms(selected(s.taxonomy))
+	}
+	s.term.Refresh()
+}
+
+// search lists the pages matching the query and filters
+func (s *ContentSearch) search() {
+	if s.index == nil {
+		return
+	}
+	q := site.SearchQuery{
+		Text:     s.query.Text,
+		Section:  selected(s.section),
+		Status:   selected(s.status),
+		Taxonomy: selected(s.taxonomy),
+		Term:     selected(s.term),
+	}
+	if s.lang != nil {
+		q.Lang = selected(s.lang)
+	}
+	s.results = s.index.Search(q)
+	s.info.SetText(fmt.Sprintf("%d of %d pages", len(s.results), len(s.index.Docs)))
+	s.list.Refresh()
+	s.list.ScrollToTop()
+}
+
+// selected returns the choice of a filter, empty for all
+func selected(sel *widget.Select) string {
+	if sel.Selected == allOption {
+		return ""
+	}
+	return sel.Selected
+}
+
+// snippetSegments shows the extract of a result with its matches in bold
+func snippetSegments(r site.SearchResult) []widget.RichTextSegment {
+	var segments []widget.RichTextSegment
+	add := func(text string, bold bool) {
+		if text == "" {
+			return
+		}
+		style := widget.RichTextStyleInline
+		if bold {
+			style = widget.RichTextStyleStrong
+		}
+		segments = append(segments, &widget.TextSegment{Text: text, Style: style})
+	}
+	last := 0
+	for _, m := range r.Matches {
+		add(r.Snippet[last:m[0]], false)
+		add(r.Snippet[m[0]:m[1]], true)
+		last = m[1]
+	}
+	add(r.Snippet[last:], false)
+	return segments
+}
@@ -0,0 +1,232 @@
+package ui
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// Settings edits the configuration of a site through forms
+type Settings struct {
+	Site *site.SiteConfig
+
+	window    fyne.Window
+	container *fyne.Container
+	form      *fyne.Container
+
+	// Dotted config key -> field, and the value it started with
+	fields map[string]*valueField
+	shown  map[string]interface{}
+}
+
+// NewSettings creates the settings screen of a site
+func NewSettings(w fyne.Window, cfg *site.SiteConfig) *Settings {
+	s := &Settings{
+		Site:   cfg,
+		window: w,
+		form:   container.NewVBox(),
+	}
+
+	saveBtn := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), s.save)
+	saveBtn.Importance = widget.HighImportance
+	topBar := container.NewBorder(
+		nil,
+		nil,
+		nil,
+		saveBtn,
+		widget.NewLabelWithStyle("Site Settings", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+
+	s.container = container.NewPadded(
+		container.NewBorder(topBar, nil, nil, nil, container.NewVScroll(s.form)),
+	)
+	s.refresh()
+
+	return s
+}
+
+// GetUI returns the container for this component
+func (s *Settings) GetUI() fyne.CanvasObject {
+	return s.container
+}
+
+// refresh rebuilds the forms from the current configuration
+func (s *Settings) refresh() {
+	s.fields = make(map[string]*valueField)
+	s.shown = make(map[string]interface{})
+
+	general := widget.NewForm()
+	add := func(label, key string, field *valueField) {
+		s.fields[key] = field
+		general.Append(label, field.widget)
+	}
+
+	add("Title", "title", newValueField(s.window, s.value("title", "")))
+	add("Base URL", "baseURL", newValueField(s.window, s.value("baseURL", "")))
+	add("Language code", "languageCode", newValueField(s.window, s.value("languageCode", "")))
+	add("Default language", "defaultContentLanguage", s.languageField())
+	add("Theme", "theme", s.themeField())
+
+	// Hugo 0.128 moved paginate to pagination.pagerSize, keep the old key
+	// for sites still using it
+	pagerKey := "pagination.pagerSize"
+	if _, ok := site.Get(s.Site.Raw, "paginate"); ok {
+		pagerKey = "paginate"
+	}
+	add("Page size", pagerKey, newValueField(s.window, s.value(pagerKey, 10)))
+
+	taxonomies := make(map[string]interface{}, len(s.Site.Taxonomies))
+	for singular, plural := range s.Site.Taxonomies {
+		taxonomies[singular] = plural
+	}
+	s.shown["taxonomies"] = taxonomies
+	s.fields["taxonomies"] = newMapField(s.window, taxonomies)
+
+	params := s.Site.Params
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	s.shown["params"] = params
+	s.fields["params"] = newMapField(s.window, params)
+
+	s.form.Objects = []fyne.CanvasObject{
+		widget.NewCard("General", "", general),
+		widget.NewCard("Taxonomies", "singular = plural, e.g. tag = tags", s.fields["taxonomies"].widget),
+		widget.NewCard("Params", "Site parameters used by the theme", s.fields["params"].widget),
+	}
+	s.form.Refresh()
+}
+
+// value returns the value of a config key, or def when it isn't set, and
+// remembers it as the value shown for the key
+func (s *Settings) value(key string, def interface{}) interface{} {
+	val, ok := site.Get(s.Site.Raw, key)
+	if !ok || val == nil {
+		val = def
+	}
+	s.shown[key] = val
+	return val
+}
+
+// languageField picks the default content language among the site's
+// languages
+func (s *Settings) languageField() *valueField {
+	codes := make([]string, len(s.Site.Languages))
+	for i, lang := range s.Site.Languages {
+		codes[i] = lang.Code
+	}
+	return newSelectField(codes, s.value("defaultContentLanguage", s.Site.DefaultContentLanguage))
+}
+
+// themeField picks a theme among the ones in the themes directory
+func (s *Settings) themeField() *valueField {
+	current := s.value("theme", "")
+	if _, ok := current.(string); !ok {
+		// Several themes, edit them as a list
+		return newValueField(s.window, current)
+	}
+
+	var themes []string
+	if entries, err := os.ReadDir(s.Site.ThemesDir()); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+				themes = append(themes, entry.Name())
+			}
+		}
+	}
+	sort.Strings(themes)
+	return newSelectField(themes, current)
+}
+
+// newSelectField is a text field offering options, which also takes values
+// that aren't among them
+func newSelectField(options []string, val interface{}) *valueField {
+	entry := widget.NewSelectEntry(options)
+	text, _ := val.(string)
+	entry.SetText(text)
+	return &valueField{
+		widget: entry,
+		value: func() (interface{}, error) {
+			return strings.TrimSpace(entry.Text), nil
+		},
+	}
+}
+
+func (s *Settings) save() {
+	changes := make(map[string]interface{})
+	for key, field := range s.fields {
+		val, err := field.value()
+		if err != nil {
+			dialog.ShowError(err, s.window)
+			return
+		}
+		diffConfig(key, s.shown[key], val, changes)
+	}
+
+	// Setting taxonomies replaces Hugo's defaults, so write them all when
+	// the site relied on the defaults until now
+	if _, ok := site.Get(s.Site.Raw, "taxonomies"); !ok && hasPrefixKey(changes, "taxonomies") {
+		for key := range changes {
+			if strings.HasPrefix(key, "taxonomies.") {
+				delete(changes, key)
+			}
+		}
+		val, _ := s.fields["taxonomies"].value()
+		changes["taxonomies"] = val
+	}
+
+	if len(changes) == 0 {
+		return
+	}
+	if err := s.Site.Save(changes); err != nil {
+		dialog.ShowError(err, s.window)
+		return
+	}
+	s.refresh()
+	dialog.ShowInformation("Settings", "Settings saved", s.window)
+}
+
+// diffConfig adds the dotted keys that differ between old and new to
+// changes, going into maps so each key is written to the file that holds
+// it. Removed keys are set to nil.
+func diffConfig(key string, old, new interface{}, changes map[string]interface{}) {
+	oldMap, oldOK := old.(map[string]interface{})
+	newMap, newOK := new.(map[string]interface{})
+	if !oldOK || !newOK {
+		if !reflect.DeepEqual(old, new) {
+			changes[key] = new
+		}
+		return
+	}
+
+	for k, v := range newMap {
+		if ov, ok := oldMap[k]; ok {
+			diffConfig(key+"."+k, ov, v, changes)
+		} else {
+			changes[key+"."+k] = v
+		}
+	}
+	for k := range oldMap {
+		if _, ok := newMap[k]; !ok {
+			changes[key+"."+k] = nil
+		}
+	}
+}
+
+func hasPrefixKey(m map[string]interface{}, prefix string) bool {
+	for key := range m {
+		if key == prefix || strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+)
+
+// showShortcodePalette lists the shortcodes of the site, its themes and
+// Hugo, and inserts a call of the one picked at the cursor
+func (e *Editor) showShortcodePalette() {
+	shortcodes := cms.FindShortcodes(e.Site.Dir, e.Site.ThemePaths())
+
+	var shown []cms.Shortcode
+	list := widget.NewList(
+		func() int { return len(shown) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(shown[id].Label())
+		},
+	)
+	filter := func(text string) {
+		shown = shown[:0]
+		for _, s := range shortcodes {
+			if strings.Contains(strings.ToLower(s.Name), strings.ToLower(text)) {
+				shown = append(shown, s)
+			}
+		}
+		list.UnselectAll()
+		list.Refresh()
+	}
+	filter("")
+
+	search := widget.NewEntry()
+	search.SetPlaceHolder("Search shortcodes")
+	search.OnChanged = filter
+
+	var d dialog.Dialog
+	list.OnSelected = func(id widget.ListItemID) {
+		s := shown[id]
+		d.Hide()
+		e.showShortcodeForm(s, s.Positional() && !s.Named(), make(map[string]string), "")
+	}
+
+	content := container.NewBorder(search, nil, nil, nil, list)
+	d = dialog.NewCustom("Insert Shortcode", "Cancel", content, e.window)
+	d.Resize(fyne.NewSize(400, 500))
+	d.Show()
+	e.window.Canvas().Focus(search)
+}
+
+// showShortcodeForm asks for the parameters of a shortcode, showing the
+// call as it is typed. A shortcode reading parameters both by name and by
+// position is called either way, picked in the form.
+func (e *Editor) showShortcodeForm(s cms.Shortcode, positional bool, values map[string]string, innerText string) {
+	call := widget.NewLabel(s.Call(values, innerText, positional))
+	call.TextStyle = fyne.TextStyle{Monospace: true}
+	call.Wrapping = fyne.TextWrapWord
+
+	inner := widget.NewMultiLineEntry()
+	inner.SetText(innerText)
+	update := func() { call.SetText(s.Call(values, inner.Text, positional)) }
+	inner.OnChanged = func(string) { update() }
+
+	var d dialog.Dialog
+	var items []*widget.FormItem
+	if s.Named() && s.Positional() {
+		kind := widget.NewRadioGroup([]string{"By name", "By position"}, nil)
+		kind.Horizontal = true
+		kind.Required = true
+		kind.Selected = "By name"
+		if positional {
+			kind.Selected = "By position"
+		}
+		kind.OnChanged = func(choice string) {
+			d.Hide()
+			e.showShortcodeForm(s, choice == "By position", values, inner.Text)
+		}
+		items = append(items, widget.NewFormItem("Parameters", kind))
+	}
+	for _, p := range s.Params {
+		if positional != (p.Position >= 0) {
+			continue
+		}
+		label := p.Label()
+		entry := widget.NewEntry()
+		entry.SetText(values[label])
+		entry.OnChanged = func(text string) {
+			values[label] = text
+			update()
+		}
+		items = append(items, widget.NewFormItem(label, entry))
+	}
+	if s.Inner {
+		items = append(items, widget.NewFormItem("Inner content", inner))
+	}
+	if len(items) == 0 {
+		e.insertText(s.Call(values, "", positional))
+		return
+	}
+	items = append(items, widget.NewFormItem("", call))
+
+	d = dialog.NewForm(s.Name, "Insert", "Cancel", items, func(ok bool) {
+		if ok {
+			e.insertText(s.Call(values, inner.Text, positional))
+		}
+	}, e.window)
+	d.Resize(fyne.NewSize(500, 0))
+	d.Show()
+}
+
+// insertText puts text in the content at the cursor
+func (e *Editor) insertText(text string) {
+	body := []rune(e.bodyEntry.Text)
+	at := e.bodyEntry.CursorTextOffset()
+	if at > len(body) {
+		at = len(body)
+	}
+	e.bodyEntry.SetText(string(body[:at]) + text + string(body[at:]))
+	e.window.Canvas().Focus(e.bodyEntry)
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// showSlugDialog makes a slug of the title, unique within the section,
+// and sets it as the slug field, the file name or both. Renaming saves
+// the page first.
+func (e *Editor) showSlugDialog() {
+	title := ""
+	if k := e.fieldKey("title"); k != "" {
+		if v, err := e.widgetMap[k].value(); err == nil {
+			title = fmt.Sprint(v)
+		}
+	}
+
+	page := site.FindPage(e.pages, e.FullPath)
+	section := ""
+	if page != nil {
+		section = page.Section()
+	}
+	isIndex, isSection := cms.IsBundleIndex(e.FullPath)
+	from, dir := e.FullPath, filepath.Dir(e.FullPath)
+	if isIndex {
+		from, dir = dir, filepath.Dir(dir) // A bundle is named by its folder
+	}
+
+	slugEntry := widget.NewEntry()
+	slugEntry.SetText(e.Site.UniqueSlug(e.pages, dir, section, e.Site.Slug(title), e.FullPath))
+	fieldCheck := widget.NewCheck("Set the slug field", nil)
+	fieldCheck.SetChecked(true)
+	renameCheck := widget.NewCheck("Rename the file", nil)
+	if isSection {
+		renameCheck.Disable() // Sections are named by their folder
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Title", widget.NewLabel(title)),
+		widget.NewFormItem("Slug", slugEntry),
+		widget.NewFormItem("", fieldCheck),
+		widget.NewFormItem("", renameCheck),
+	}
+	d := dialog.NewForm("Slug", "Apply", "Cancel", items, func(ok bool) {
+		slug := e.Site.Slug(slugEntry.Text)
+		if !ok || slug == "" {
+			return
+		}
+		if fieldCheck.Checked {
+			k := e.fieldKey("slug")
+			if k == "" {
+				k = "slug"
+			}
+			e.addField(k, slug)
+			e.refreshForm()
+		}
+		if !renameCheck.Checked {
+			return
+		}
+
+		if !e.save() {
+			return
+		}
+		to := filepath.Join(dir, slug)
+		moves := map[string]string{from: to}
+		if !isIndex {
+			moves = e.Site.TranslationMoves(from, to+filepath.Ext(from))
+		}
+		if from == to || moves[e.FullPath] == e.FullPath {
+			return // Already named so
+		}
+		pages, err := e.Site.Pages()
+		if err != nil {
+			dialog.ShowError(err, e.window)
+			return
+		}
+		if err := e.Site.MovePages(pages, moves); err != nil {
+			dialog.ShowError(err, e.window)
+			return
+		}
+		path := moves[e.FullPath]
+		if isIndex {
+			path = filepath.Join(to, filepath.Base(e.FullPath))
+		}
+		e.open(path)
+	}, e.window)
+	d.Resize(fyne.NewSize(400, 300))
+	d.Show()
+}
+
+// fieldKey returns the key of a front matter field as written, ignoring
+// case, or "" when the page has none
+func (e *Editor) fieldKey(k string) string {
+	for _, key := range e.keys {
+		if strings.EqualFold(key, k) {
+			return key
+		}
+	}
+	return ""
+}
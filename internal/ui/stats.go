@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// statsView shows the word count and reading time of the content as
+// edited, and the summary list pages will show
+func (e *Editor) statsView() fyne.CanvasObject {
+	page := &site.Page{Path: e.FullPath, Meta: make(map[string]interface{}), Body: e.bodyEntry.Text}
+	for k, field := range e.widgetMap {
+		if val, err := field.value(); err == nil {
+			page.Meta[k] = val
+		}
+	}
+
+	words := cms.CountWords(cms.PlainText(page.Body))
+	cjk := e.Site.IsCJKLanguage(page)
+	minutes := cms.ReadingTime(words, cjk)
+
+	summaryText := ""
+	if field, ok := e.widgetMap["summary"]; ok {
+		if val, err := field.value(); err == nil {
+			summaryText = cms.FormatValue(val)
+		}
+	}
+	summary, source, truncated := cms.Summary(page.Body, summaryText, e.Site.SummaryLength)
+
+	form := widget.NewForm(
+		widget.NewFormItem("Words", widget.NewLabel(fmt.Sprint(words))),
+		widget.NewFormItem("Reading time", widget.NewLabel(fmt.Sprintf("%d min", minutes))),
+	)
+
+	about := "Summary from the " + source
+	if source == cms.SummaryAutomatic {
+		about = fmt.Sprintf("Automatic summary: whole paragraphs up to %d words (summaryLength)", e.Site.SummaryLength)
+	}
+	if truncated {
+		about += ", followed by a Read more link"
+	}
+	summaryView := widget.NewRichTextFromMarkdown(summary)
+	summaryView.Wrapping = fyne.TextWrapWord
+
+	return container.NewVBox(
+		form,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle(about, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		summaryView,
+	)
+}
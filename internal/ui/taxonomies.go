@@ -0,0 +1,227 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// TaxonomyBrowser lists the terms of the site's taxonomies with the pages
+// using them, and renames, merges and deletes terms across all content
+type TaxonomyBrowser struct {
+	Site *site.SiteConfig
+
+	window    fyne.Window
+	container *fyne.Container
+	taxSelect *widget.Select
+	filter    *widget.Entry
+	list      *widget.List
+	detail    *fyne.Container
+
+	pages    []*site.Page
+	index    []*site.Taxonomy
+	current  *site.Taxonomy
+	terms    []*site.Term // The terms shown, after filtering
+	selected *site.Term
+}
+
+// NewTaxonomyBrowser creates the taxonomy screen of a site. The content is
+// read when it is refreshed.
+func NewTaxonomyBrowser(w fyne.Window, cfg *site.SiteConfig) *TaxonomyBrowser {
+	t := &TaxonomyBrowser{
+		Site:   cfg,
+		window: w,
+		detail: container.NewVBox(),
+	}
+
+	t.taxSelect = widget.NewSelect(nil, func(plural string) {
+		t.current = site.FindTaxonomy(t.index, plural)
+		t.selectTerm(nil)
+		t.applyFilter()
+	})
+
+	t.filter = widget.NewEntry()
+	t.filter.SetPlaceHolder("Filter terms")
+	t.filter.OnChanged = func(string) { t.applyFilter() }
+
+	t.list = widget.NewList(
+		func() int {
+			return len(t.terms)
+		},
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, widget.NewLabel(""), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			term := t.terms[id]
+			row := o.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(term.Name)
+			row.Objects[1].(*widget.Label).SetText(fmt.Sprint(len(term.Pages)))
+		},
+	)
+	t.list.OnSelected = func(id widget.ListItemID) {
+		t.selectTerm(t.terms[id])
+	}
+
+	refreshBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), t.refresh)
+	topBar := container.NewBorder(nil, nil, t.taxSelect, refreshBtn, t.filter)
+
+	split := container.NewHSplit(t.list, container.NewVScroll(t.detail))
+	split.Offset = 0.4
+
+	t.container = container.NewPadded(
+		container.NewBorder(topBar, nil, nil, nil, split),
+	)
+
+	return t
+}
+
+// GetUI returns the container for this component
+func (t *TaxonomyBrowser) GetUI() fyne.CanvasObject {
+	return t.container
+}
+
+// refresh reads the content again and rebuilds the index
+func (t *TaxonomyBrowser) refresh() {
+	pages, err := t.Site.Pages()
+	if err != nil {
+		fyne.LogError("Failed to read content", err)
+	}
+	t.pages = pages
+	t.index = t.Site.TaxonomyIndex(pages)
+
+	options := make([]string, len(t.index))
+	for i, tax := range t.index {
+		options[i] = tax.Plural
+	}
+	t.taxSelect.Options = options
+
+	selected := t.taxSelect.Selected
+	if site.FindTaxonomy(t.index, selected) == nil && len(options) > 0 {
+		selected = options[0]
+	}
+	t.taxSelect.Selected = selected
+	t.taxSelect.Refresh()
+
+	t.current = site.FindTaxonomy(t.index, selected)
+	t.selectTerm(nil)
+	t.applyFilter()
+}
+
+func (t *TaxonomyBrowser) applyFilter() {
+	t.terms = nil
+	if t.current != nil {
+		filter := strings.ToLower(strings.TrimSpace(t.filter.Text))
+		for _, term := range t.current.Terms {
+			if strings.Contains(strings.ToLower(term.Name), filter) {
+				t.terms = append(t.terms, term)
+			}
+		}
+	}
+	t.list.UnselectAll()
+	t.list.Refresh()
+}
+
+// selectTerm shows a term with its pages and the actions on it
+func (t *TaxonomyBrowser) selectTerm(term *site.Term) {
+	t.selected = term
+	if term == nil {
+		t.detail.Objects = []fyne.CanvasObject{
+			widget.NewLabel("Select a term to see its pages"),
+		}
+		t.detail.Refresh()
+		return
+	}
+
+	renameBtn := widget.NewButtonWithIcon("Rename", theme.DocumentCreateIcon(), t.showRenameDialog)
+	mergeBtn := widget.NewButtonWithIcon("Merge", theme.ContentCopyIcon(), t.showMergeDialog)
+	deleteBtn := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), t.confirmDelete)
+	deleteBtn.Importance = widget.DangerImportance
+
+	objects := []fyne.CanvasObject{
+		widget.NewLabelWithStyle(term.Name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewHBox(renameBtn, mergeBtn, deleteBtn),
+	}
+	if len(term.Spelling) > 1 {
+		objects = append(objects, widget.NewLabel("Also written as: "+strings.Join(term.Spelling[1:], ", ")))
+	}
+	objects = append(objects, widget.NewLabelWithStyle(
+		fmt.Sprintf("%d pages", len(term.Pages)), fyne.TextAlignLeading, fyne.TextStyle{Italic: true}))
+	for _, p := range term.Pages {
+		objects = append(objects, widget.NewLabel(p.Title()+"  ·  "+p.Rel))
+	}
+
+	t.detail.Objects = objects
+	t.detail.Refresh()
+}
+
+func (t *TaxonomyBrowser) showRenameDialog() {
+	term := t.selected
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(term.Name)
+	items := []*widget.FormItem{widget.NewFormItem("New name", nameEntry)}
+
+	renameDialog := dialog.NewForm("Rename "+term.Name, "Rename", "Cancel", items, func(ok bool) {
+		name := strings.TrimSpace(nameEntry.Text)
+		if !ok || name == "" {
+			return
+		}
+		t.replace(term.Spelling, name)
+	}, t.window)
+
+	renameDialog.Resize(fyne.NewSize(400, 170))
+	renameDialog.Show()
+}
+
+func (t *TaxonomyBrowser) showMergeDialog() {
+	term := t.selected
+	var names []string
+	for _, other := range t.current.Terms {
+		if other != term {
+			names = append(names, other.Name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	targetSelect := widget.NewSelect(names, nil)
+	items := []*widget.FormItem{widget.NewFormItem("Merge into", targetSelect)}
+
+	mergeDialog := dialog.NewForm("Merge "+term.Name, "Merge", "Cancel", items, func(ok bool) {
+		if !ok || targetSelect.Selected == "" {
+			return
+		}
+		t.replace(term.Spelling, targetSelect.Selected)
+	}, t.window)
+
+	mergeDialog.Resize(fyne.NewSize(400, 170))
+	mergeDialog.Show()
+}
+
+func (t *TaxonomyBrowser) confirmDelete() {
+	term := t.selected
+	message := fmt.Sprintf("Remove %q from %d pages?", term.Name, len(term.Pages))
+	dialog.ShowConfirm("Delete "+term.Name, message, func(ok bool) {
+		if ok {
+			t.replace(term.Spelling, "")
+		}
+	}, t.window)
+}
+
+// replace writes the change of a term to every page using it
+func (t *TaxonomyBrowser) replace(from []string, to string) {
+	changed, err := site.RenameTerm(t.pages, t.current.Plural, from, to)
+	t.refresh()
+	if err != nil {
+		dialog.ShowError(err, t.window)
+		return
+	}
+	dialog.ShowInformation("Taxonomies", fmt.Sprintf("%d pages updated", changed), t.window)
+}
@@ -0,0 +1,272 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"reflect"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	cms "github.com/GopherGhaznix/Bayan/internal/hugo"
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// sideBySideOff is the option of sourceSelect going back to the tabs
+const sideBySideOff = "Off"
+
+// sourceSelect switches to translating side by side with another language
+// version of the page. It is nil when the page has no other version.
+func (e *Editor) sourceSelect() *widget.Select {
+	page := site.FindPage(e.pages, e.FullPath)
+	if !e.Site.Multilingual() || page == nil {
+		return nil
+	}
+
+	translations := site.Translations(e.pages, page)
+	var options []string
+	for _, lang := range e.Site.Languages {
+		if _, ok := translations[lang.Code]; ok && lang.Code != page.Lang {
+			options = append(options, lang.Code)
+		}
+	}
+	if len(options) == 0 {
+		return nil
+	}
+
+	sourceSelect := widget.NewSelect(append(options, sideBySideOff), func(code string) {
+		if code == sideBySideOff {
+			e.refreshForm()
+			e.main.Objects = []fyne.CanvasObject{e.tabs}
+			e.main.Refresh()
+			return
+		}
+		e.showSideBySide(translations[code])
+	})
+	sourceSelect.PlaceHolder = "Translate from"
+	return sourceSelect
+}
+
+// showSideBySide shows the source page read-only next to this one, with
+// front matter fields paired by key and the content aligned by headings and
+// paragraphs. What still reads like the source is highlighted.
+func (e *Editor) showSideBySide(source *site.Page) {
+	content, err := os.ReadFile(source.Path)
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	src, err := cms.ParseMD(string(content))
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+
+	rows := container.NewVBox()
+	header := func(left, right string) {
+		rows.Add(container.NewGridWithColumns(2,
+			widget.NewLabelWithStyle(left, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(right, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		))
+	}
+	header(langName(e.Site, source.Lang), langName(e.Site, site.FindPage(e.pages, e.FullPath).Lang))
+
+	// Front matter, in the order of the source with the fields only this
+	// page has at the end
+	keys := src.Keys()
+	for _, k := range e.keys {
+		if _, ok := src.MetaData[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range keys {
+		k := k
+		srcVal, inSource := src.MetaData[k]
+		left := widget.NewLabel(k + ": " + cms.FormatValue(srcVal))
+		left.Wrapping = fyne.TextWrapWord
+		if !inSource {
+			left.SetText(k + ": —")
+		}
+
+		field, ok := e.widgetMap[k]
+		var right fyne.CanvasObject
+		untranslated := false
+		if ok {
+			right = field.widget
+			if val, err := field.value(); err == nil {
+				untranslated = isUntranslated(srcVal, val)
+			}
+		} else {
+			untranslated = true
+			right = widget.NewButtonWithIcon("Add from source", theme.ContentAddIcon(), func() {
+				e.addField(k, copyValue(srcVal))
+				e.showSideBySide(source)
+			})
+		}
+		rows.Add(pairRow(left, right, untranslated))
+	}
+
+	// Content, edited block by block
+	rows.Add(widget.NewSeparator())
+	srcBlocks := cms.SplitBlocks(src.Body)
+	dstBlocks := cms.SplitBlocks(e.bodyEntry.Text)
+	pairs := cms.AlignBlocks(srcBlocks, dstBlocks)
+
+	// The blocks of the translation by pair, nil where it has none yet. An
+	// edit replaces its block in the body, the rest stays as it was.
+	dst := make([]*cms.Block, len(pairs))
+	for i, pair := range pairs {
+		if pair[1] >= 0 {
+			dst[i] = &dstBlocks[pair[1]]
+		}
+	}
+	shift := func(from int, delta int, except *cms.Block) {
+		for _, b := range dst {
+			if b != nil && b != except && b.Start >= from {
+				b.Start += delta
+				b.End += delta
+			}
+		}
+	}
+	edit := func(i int, text string) {
+		body := e.bodyEntry.Text
+		if b := dst[i]; b != nil {
+			edited := cms.ReplaceBlock(body, *b, text)
+			delta := len(edited) - len(body)
+			shift(b.End, delta, b)
+			b.End += delta
+			b.Text = text
+			e.bodyEntry.SetText(edited)
+			return
+		}
+
+		// Goes after the block before it, or first
+		at := 0
+		var prev *cms.Block
+		for j := i - 1; j >= 0 && prev == nil; j-- {
+			prev = dst[j]
+		}
+		if prev != nil {
+			at = prev.End
+		}
+		edited, b := cms.InsertBlock(body, at, text)
+		shift(at, len(edited)-len(body), prev)
+		dst[i] = &b
+		e.bodyEntry.SetText(edited)
+	}
+
+	for i, pair := range pairs {
+		i := i
+		srcText, dstText := "", ""
+		if pair[0] >= 0 {
+			srcText = srcBlocks[pair[0]].Text
+		}
+		if dst[i] != nil {
+			dstText = dst[i].Text
+		}
+
+		left := widget.NewLabel(srcText)
+		left.Wrapping = fyne.TextWrapWord
+
+		entry := widget.NewMultiLineEntry()
+		entry.Wrapping = fyne.TextWrapWord
+		entry.SetText(dstText)
+		entry.OnChanged = func(text string) {
+			edit(i, text)
+		}
+
+		untranslated := srcText != "" && (dstText == "" || dstText == srcText)
+		rows.Add(pairRow(left, entry, untranslated))
+	}
+
+	e.main.Objects = []fyne.CanvasObject{container.NewVScroll(rows)}
+	e.main.Refresh()
+}
+
+// pairRow puts a source and a translation next to each other, highlighted
+// when the translation is missing
+func pairRow(left, right fyne.CanvasObject, untranslated bool) fyne.CanvasObject {
+	row := container.NewGridWithColumns(2, left, right)
+	if !untranslated {
+		return row
+	}
+	return container.NewStack(newHighlight(), row)
+}
+
+// newHighlight is a faint warning colored background
+func newHighlight() *canvas.Rectangle {
+	r, g, b, _ := theme.Color(theme.ColorNameWarning).RGBA()
+	return canvas.NewRectangle(color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0x30})
+}
+
+// isUntranslated reports whether a field still holds the text of the
+// source. Values other than text, like dates, are the same in every
+// language.
+func isUntranslated(src, val interface{}) bool {
+	switch s := src.(type) {
+	case string:
+		return s != "" && reflect.DeepEqual(src, val)
+	case []interface{}, map[string]interface{}:
+		return containsText(src) && reflect.DeepEqual(src, val)
+	}
+	return false
+}
+
+func containsText(val interface{}) bool {
+	switch v := val.(type) {
+	case string:
+		return v != ""
+	case []interface{}:
+		for _, item := range v {
+			if containsText(item) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			if containsText(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// copyValue deep copies maps and lists, so editing the copy leaves the
+// source alone
+func copyValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = copyValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = copyValue(item)
+		}
+		return out
+	case []map[string]interface{}:
+		out := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			out[i] = copyValue(item).(map[string]interface{})
+		}
+		return out
+	}
+	return val
+}
+
+// langName returns the name of a language for headings
+func langName(cfg *site.SiteConfig, code string) string {
+	if lang, ok := cfg.Language(code); ok && lang.Name != "" {
+		return fmt.Sprintf("%s (%s)", lang.Name, code)
+	}
+	return code
+}
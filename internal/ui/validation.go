@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/GopherGhaznix/Bayan/internal/site"
+)
+
+// validate checks the page as edited against the front matter rules of the
+// site and shows the problems by their fields. It reports whether the
+// page follows the rules.
+func (e *Editor) validate(p *site.Page) bool {
+	rules, err := e.Site.LoadRules()
+	if err != nil {
+		dialog.ShowError(err, e.window)
+	}
+
+	e.problems = make(map[string][]string)
+	errs := rules.Validate(p)
+	for _, err := range errs {
+		e.problems[err.Key] = append(e.problems[err.Key], err.Message)
+	}
+	e.refreshForm()
+	return len(errs) == 0
+}
+
+// problemsView lists the rules broken by fields the page doesn't have,
+// with a button to add them
+func (e *Editor) problemsView() fyne.CanvasObject {
+	keys := make([]string, 0, len(e.problems))
+	for k := range e.problems {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	box := container.NewVBox()
+	for _, k := range keys {
+		if e.fieldKey(k) != "" {
+			continue // Shown under its field
+		}
+		k := k
+		label := widget.NewLabel(k + " " + strings.Join(e.problems[k], ", "))
+		label.Importance = widget.DangerImportance
+		if strings.Contains(k, ".") {
+			box.Add(label) // A nested value, added within its group
+			continue
+		}
+		addBtn := widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), func() {
+			e.addField(k, "")
+			e.refreshForm()
+		})
+		addBtn.Importance = widget.LowImportance
+		box.Add(container.NewBorder(nil, nil, nil, addBtn, label))
+	}
+	return box
+}
+
+// showValidationErrors lists the pages breaking the front matter rules.
+// Tapping one opens its page.
+func showValidationErrors(w fyne.Window, errs []site.ValidationError, open func(path string)) {
+	const title = "Front Matter Rules"
+	if len(errs) == 0 {
+		dialog.ShowInformation(title, "Every page follows the rules.", w)
+		return
+	}
+
+	var d dialog.Dialog
+	list := widget.NewList(
+		func() int { return len(errs) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel(""),
+			)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			err := errs[id]
+			box := o.(*fyne.Container)
+			box.Objects[0].(*widget.Label).SetText(err.Page.Rel)
+			box.Objects[1].(*widget.Label).SetText(err.Error())
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		d.Hide()
+		open(errs[id].Page.Path)
+	}
+
+	message := widget.NewLabel(fmt.Sprintf("%d problems in the front matter of pages.", len(errs)))
+	d = dialog.NewCustom(title, "Close", container.NewBorder(message, nil, nil, nil, list), w)
+	d.Resize(fyne.NewSize(600, 450))
+	d.Show()
+}
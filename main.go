@@ -11,6 +11,7 @@ import (
 	"fyne.io/fyne/v2/app"
 
 	"github.com/GopherGhaznix/Bayan/config"
+	"github.com/GopherGhaznix/Bayan/internal/site"
 	"github.com/GopherGhaznix/Bayan/internal/ui"
 )
 
@@ -47,18 +48,26 @@ func main() {
 	// View: [SiteSelector] -> [FileExplorer] -> [Editor]
 
 	selector = ui.NewSiteSelector(w, config.BaseConfig.WebsiteRoot, func(sitePath string) {
-		// User selected a site. Open FileExplorer at sitePath/content
-		contentPath := filepath.Join(sitePath, "content")
+		// User selected a site. Read its Hugo configuration
+		cfg, err := site.Load(sitePath, "")
+		if err != nil {
+			fyne.LogError("Failed to load site config", err)
+			cfg = site.New(sitePath)
+		}
+
+		// Open FileExplorer at the content directory
+		contentPath := cfg.ContentPath()
 		if _, err := os.Stat(contentPath); os.IsNotExist(err) {
 			// Fallback if content dir missing, just use site root
 			contentPath = sitePath
 		}
 
 		var explorer *ui.FileExplorer
-		explorer = ui.NewFileExplorer(w, contentPath, func(filePath string) {
+		explorer = ui.NewFileExplorer(w, cfg, contentPath, func(filePath string) {
 			// User selected a file. Open Editor
-			editor := ui.NewEditor(w, filePath, func() {
-				// Close Editor -> Back to Explorer
+			editor := ui.NewEditor(w, cfg, filePath, func() {
+				// Close Editor -> Back to Explorer, which may show new translations
+				explorer.Reload()
 				w.SetContent(explorer.GetUI())
 			})
 			w.SetContent(editor.GetUI())